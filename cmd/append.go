@@ -3,14 +3,51 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 
 	"github.com/spf13/cobra"
 )
 
+// appendToDocument adds template sections to an existing document.
+// includeCommon が true で共通区画がまだ無い場合は、既存の内容に含まれていない
+// 共通パターンだけを共通区画としてテンプレートの前に追加します
+func appendToDocument(doc *Document, common []byte, sections []Section, includeCommon, dedupe bool) {
+	if includeCommon && len(common) > 0 && !doc.Has(commonSectionName) {
+		doc.Upsert(Section{Name: commonSectionName, Content: common})
+		doc.dedupeCommon()
+	}
+
+	// 同名の区画があれば置き換え、なければ末尾に追記
+	for _, s := range sections {
+		doc.Upsert(s)
+	}
+	if dedupe {
+		doc.Dedupe()
+	}
+}
+
+// composeAppend loads the named templates and common.gitignore and adds them to doc
+func composeAppend(doc *Document, sources Sources, configDir string, names []string, includeCommon, dedupe bool) ([]Section, error) {
+	// すべてのテンプレートを読み込む（1つでも欠けていれば書き込む前に中断）
+	sections, err := loadTemplates(sources, names)
+	if err != nil {
+		return nil, err
+	}
+
+	// common.gitignore を連結する場合のみ読み込む
+	var common []byte
+	if includeCommon {
+		if common, err = loadCommon(configDir, sources); err != nil {
+			return nil, err
+		}
+	}
+
+	appendToDocument(doc, common, sections, includeCommon, dedupe)
+	return sections, nil
+}
+
 var appendCmd = &cobra.Command{
-	Use:   "append [template]",
-	Short: "Append template to existing .gitignore",
+	Use:   "append [template...]",
+	Short: "Append templates to existing .gitignore",
 	Args:  cobra.ArbitraryArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		// 既存の出力ファイルが存在するか確認
@@ -19,10 +56,10 @@ var appendCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		// キャッシュディレクトリのパスを解決
-		cacheDir, err := getCacheDir()
+		// テンプレートの取得元を解決
+		sources, err := getSources()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error getting cache directory: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error resolving template sources: %v\n", err)
 			os.Exit(1)
 		}
 
@@ -33,87 +70,59 @@ var appendCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		var template string
+		var templates []string
 		if interactive {
 			// インタラクティブモード
-			template, err = runInteractiveSelector(cacheDir)
+			// 確定前に既存のファイルとの差分を表示
+			confirm := func(names []string) (string, error) {
+				existing, doc, err := readDocument(outputPath)
+				if err != nil {
+					return "", err
+				}
+				if _, err := composeAppend(doc, sources, configDir, names, !noCommon, !noDedupe); err != nil {
+					return "", err
+				}
+				return colorDiff(unifiedDiff(existing, doc.Bytes(), outputPath, outputPath)), nil
+			}
+			selected, err := runInteractiveSelector(sources, category, confirm)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error in interactive mode: %v\n", err)
 				os.Exit(1)
 			}
-			if template == "" {
+			if len(selected) == 0 {
 				fmt.Println("No template selected")
 				return
 			}
+			templates = selected
 		} else {
 			// 非インタラクティブモード
 			if len(args) < 1 {
 				fmt.Fprintln(os.Stderr, "Error: template name is required")
 				os.Exit(1)
 			}
-			template = args[0]
+			templates = args
 		}
 
 		// キャッシュの存在確認と更新
 		skipUpdate := noUpdate || config.NoUpdate
-		if err := EnsureCache(cacheDir, skipUpdate); err != nil {
+		if err := sources.Ensure(skipUpdate); err != nil {
 			fmt.Fprintf(os.Stderr, "Error managing cache: %v\n", err)
 			os.Exit(1)
 		}
 
-		// テンプレートファイルのパスを構築
-		templatePath := filepath.Join(cacheDir, template+".gitignore")
-
-		// テンプレートファイルの内容を読み込む
-		templateContent, err := os.ReadFile(templatePath)
+		// 既存の .gitignore を読み込み、管理区画と手書きの行に分解
+		_, doc, err := readDocument(outputPath)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading template file %s: %v\n", templatePath, err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 
-		// 既存の .gitignore を読み込む
-		existingContent, err := os.ReadFile(outputPath)
+		sections, err := composeAppend(doc, sources, configDir, templates, !noCommon, !noDedupe)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading existing %s: %v\n", outputPath, err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
-
-		// common.gitignore を連結するかどうか
-		var finalContent []byte
-		if !noCommon {
-			// 共通無視ファイルの存在確認と作成
-			commonIgnorePath, err := EnsureCommonIgnore(configDir)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error managing common.gitignore: %v\n", err)
-				os.Exit(1)
-			}
-
-			// 共通無視ファイルの内容を読み込む
-			commonContent, err := os.ReadFile(commonIgnorePath)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error reading common.gitignore file: %v\n", err)
-				os.Exit(1)
-			}
-
-			// インポートを解決
-			var resolvedCommon []byte
-			if len(commonContent) > 0 {
-				resolvedCommon, err = ResolveImports(commonContent, cacheDir)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "Error resolving imports in common.gitignore: %v\n", err)
-					os.Exit(1)
-				}
-			}
-
-			if len(resolvedCommon) > 0 {
-				finalContent = append(finalContent, resolvedCommon...)
-				finalContent = append(finalContent, '\n')
-			}
-		}
-
-		// 既存の内容に追記
-		finalContent = append(existingContent, '\n')
-		finalContent = append(finalContent, templateContent...)
+		finalContent := doc.Bytes()
 
 		// --print が指定されたら標準出力に表示
 		if print {
@@ -121,13 +130,31 @@ var appendCmd = &cobra.Command{
 			return
 		}
 
+		// --diff / --dry-run が指定されたら差分のみ表示
+		if showDiff || dryRun {
+			previewDiff(outputPath, finalContent, showDiff)
+			return
+		}
+
+		// 追跡中のファイルが新たに無視される場合は警告（--force が無ければ中断）
+		checkTrackedFiles(outputPath, finalContent, force)
+
 		// 結果を出力ファイルに書き込み
 		if err := os.WriteFile(outputPath, finalContent, 0644); err != nil {
 			fmt.Fprintf(os.Stderr, "Error writing to %s: %v\n", outputPath, err)
 			os.Exit(1)
 		}
 
-		fmt.Printf("✨️ Successfully appended %s to .gitignore\n", template)
+		// mushi.lock に生成内容を記録
+		if !noLock {
+			if err := recordLock(outputPath, finalContent, doc, sources, configDir, sections, !noDedupe); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to update %s: %v\n", lockFileName, err)
+			}
+		}
+
+		for _, template := range templates {
+			fmt.Printf("✨️ Successfully appended %s to %s\n", template, outputPath)
+		}
 	},
 }
 
@@ -137,10 +164,17 @@ var (
 )
 
 func init() {
-	appendCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Interactively select a template")
+	appendCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Interactively select templates")
+	appendCmd.Flags().StringVar(&category, "category", "", "Only offer templates in this category in interactive mode (root, global, community)")
+	appendCmd.Flags().BoolVarP(&force, "force", "f", false, "Write even if the result would ignore tracked files")
 	appendCmd.Flags().BoolVar(&noUpdate, "no-update", false, "Skip updating the local cache")
+	appendCmd.Flags().StringVar(&pinRef, "ref", "", "Pin the github/gitignore cache to a commit SHA, tag or branch")
 	appendCmd.Flags().BoolVar(&noCommon, "no-common", false, "Do not include common.gitignore patterns")
+	appendCmd.Flags().BoolVar(&noDedupe, "no-dedupe", false, "Keep duplicate patterns across sections")
 	appendCmd.Flags().BoolVar(&print, "print", false, "Print the result to stdout instead of writing to .gitignore")
+	appendCmd.Flags().BoolVar(&showDiff, "diff", false, "Print a unified diff instead of writing, and exit with status 1 if the file would change")
+	appendCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print a unified diff instead of writing")
+	appendCmd.Flags().BoolVar(&noLock, "no-lock", false, "Do not record the result in mushi.lock")
 	appendCmd.Flags().StringVarP(&outputPath, "path", "p", ".gitignore", "Path to output file (default: .gitignore)")
 	RootCmd.AddCommand(appendCmd)
 }
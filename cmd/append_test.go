@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAppendToDocument(t *testing.T) {
+	common := []byte(".DS_Store\n*.log\n")
+	sections := []Section{{Name: "Go", Content: []byte("bin/\n")}}
+
+	tests := []struct {
+		name          string
+		existing      string
+		includeCommon bool
+		expected      string
+	}{
+		{
+			name:          "adds only missing common patterns",
+			existing:      "*.log\n",
+			includeCommon: true,
+			expected: "*.log\n\n# >>> mushi: common.gitignore\n.DS_Store\n# <<< mushi: common.gitignore\n\n" +
+				"# >>> mushi: Go\nbin/\n# <<< mushi: Go\n",
+		},
+		{
+			name:          "skips common when disabled",
+			existing:      "*.log\n",
+			includeCommon: false,
+			expected:      "*.log\n\n# >>> mushi: Go\nbin/\n# <<< mushi: Go\n",
+		},
+		{
+			name:          "keeps existing common section",
+			existing:      "# >>> mushi: common.gitignore\n.env\n# <<< mushi: common.gitignore\n",
+			includeCommon: true,
+			expected: "# >>> mushi: common.gitignore\n.env\n# <<< mushi: common.gitignore\n\n" +
+				"# >>> mushi: Go\nbin/\n# <<< mushi: Go\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ParseDocument([]byte(tt.existing))
+			if err != nil {
+				t.Fatal(err)
+			}
+			appendToDocument(doc, common, sections, tt.includeCommon, true)
+			if string(doc.Bytes()) != tt.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", tt.expected, doc.Bytes())
+			}
+		})
+	}
+}
+
+// TestAppendCommand は append コマンドを --no-common と --print の組み合わせで実行します
+func TestAppendCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(tmpDir, "cache"))
+
+	// キャッシュと common.gitignore を事前に用意
+	cacheDir := filepath.Join(tmpDir, "cache", "mushi", "github-gitignore")
+	configDir := filepath.Join(tmpDir, "config", "mushi")
+	for _, dir := range []string{cacheDir, configDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(cacheDir, "Go.gitignore"), []byte("bin/\n*.log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "common.gitignore"), []byte(".env\n*.log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	const existing = "local/\n"
+	withCommon := "local/\n\n# >>> mushi: common.gitignore\n.env\n*.log\n# <<< mushi: common.gitignore\n\n" +
+		"# >>> mushi: Go\nbin/\n# <<< mushi: Go\n"
+	withoutCommon := "local/\n\n# >>> mushi: Go\nbin/\n*.log\n# <<< mushi: Go\n"
+
+	tests := []struct {
+		name     string
+		noCommon bool
+		print    bool
+		expected string
+	}{
+		{name: "common", noCommon: false, print: false, expected: withCommon},
+		{name: "no common", noCommon: true, print: false, expected: withoutCommon},
+		{name: "common print", noCommon: false, print: true, expected: withCommon},
+		{name: "no common print", noCommon: true, print: true, expected: withoutCommon},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".gitignore")
+			if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			args := []string{"append", "Go", "--no-update", "--path", path}
+			if tt.noCommon {
+				args = append(args, "--no-common")
+			} else {
+				args = append(args, "--no-common=false")
+			}
+			if tt.print {
+				args = append(args, "--print")
+			} else {
+				args = append(args, "--print=false")
+			}
+
+			// os.Stdout を一時的に差し替え
+			origStdout := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+
+			RootCmd.SetArgs(args)
+			err := RootCmd.Execute()
+
+			w.Close()
+			stdout, _ := io.ReadAll(r)
+			os.Stdout = origStdout
+			if err != nil {
+				t.Fatalf("append failed: %v", err)
+			}
+
+			written, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.print {
+				if !strings.HasSuffix(string(stdout), tt.expected) {
+					t.Errorf("expected stdout to end with:\n%q\ngot:\n%q", tt.expected, stdout)
+				}
+				if string(written) != existing {
+					t.Errorf("--print should not modify the file, got:\n%q", written)
+				}
+				return
+			}
+			if string(written) != tt.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", tt.expected, written)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 )
@@ -16,19 +17,47 @@ var cacheUpdateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "Update the local cache",
 	Run: func(cmd *cobra.Command, args []string) {
-		// キャッシュディレクトリが存在しない場合は、自動的に取得
-		if _, err := os.Stat(CacheDir); os.IsNotExist(err) {
-			fmt.Println("Cache not found. Cloning github/gitignore repository...")
-			if err := cloneCache(CacheDir); err != nil {
-				fmt.Fprintf(os.Stderr, "Error cloning cache: %v\n", err)
-				os.Exit(1)
+		sources, err := getSources()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving template sources: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, src := range sources {
+			// ローカルディレクトリはキャッシュしない
+			if src.Local {
+				continue
 			}
-		} else {
-			// キャッシュディレクトリが存在する場合は、更新を確認
-			fmt.Println("Updating cache...")
-			if err := updateCache(CacheDir); err != nil {
-				fmt.Fprintf(os.Stderr, "Error updating cache: %v\n", err)
-				os.Exit(1)
+
+			// ref で固定されている場合は、その ref をチェックアウト
+			if src.Ref != "" {
+				if err := ensureRepository(src, false); err != nil {
+					fmt.Fprintf(os.Stderr, "Error updating cache: %v\n", err)
+					os.Exit(1)
+				}
+				continue
+			}
+
+			// キャッシュディレクトリが存在しない場合は、自動的に取得
+			if _, err := os.Stat(src.Dir); os.IsNotExist(err) {
+				if err := cloneOrExtract(src.URL, src.Dir, src.snapshotFallback()); err != nil {
+					fmt.Fprintf(os.Stderr, "Error cloning cache: %v\n", err)
+					os.Exit(1)
+				}
+			} else if isSnapshot(src.Dir) {
+				// スナップショットから作ったキャッシュはクローンし直す
+				fmt.Printf("Replacing snapshot cache for %s with a fresh clone...\n", src.Name)
+				if err := recloneCache(src.URL, src.Dir); err != nil {
+					fmt.Fprintf(os.Stderr, "Error updating cache: %v\n", err)
+					os.Exit(1)
+				}
+			} else {
+				// キャッシュディレクトリが存在する場合は、更新を確認
+				fmt.Printf("Updating cache for %s...\n", src.Name)
+				if err := updateCache(src.Dir); err != nil {
+					fmt.Fprintf(os.Stderr, "Error updating cache: %v\n", err)
+					os.Exit(1)
+				}
 			}
 		}
 	},
@@ -38,25 +67,108 @@ var cacheCleanCmd = &cobra.Command{
 	Use:   "clean",
 	Short: "Clean the local cache",
 	Run: func(cmd *cobra.Command, args []string) {
-		// キャッシュディレクトリが存在するか確認
-		if _, err := os.Stat(CacheDir); os.IsNotExist(err) {
+		sources, err := getSources()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving template sources: %v\n", err)
+			os.Exit(1)
+		}
+
+		cacheDir, err := getCacheDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting cache directory: %v\n", err)
+			os.Exit(1)
+		}
+		cacheRoot := filepath.Dir(cacheDir)
+
+		removed := false
+		for _, src := range sources {
+			// ローカルディレクトリはユーザーのものなので削除しない
+			if src.Local {
+				continue
+			}
+			// mushi のキャッシュの外にあるディレクトリは決して削除しない
+			if !insideDir(cacheRoot, src.Dir) {
+				fmt.Fprintf(os.Stderr, "Error: refusing to remove %s outside the cache directory %s\n", src.Dir, cacheRoot)
+				os.Exit(1)
+			}
+
+			// キャッシュディレクトリが存在するか確認
+			if _, err := os.Stat(src.Dir); os.IsNotExist(err) {
+				continue
+			}
+
+			// キャッシュディレクトリを削除
+			fmt.Printf("Removing cache directory: %s\n", src.Dir)
+			if err := os.RemoveAll(src.Dir); err != nil {
+				fmt.Fprintf(os.Stderr, "Error removing cache directory: %v\n", err)
+				os.Exit(1)
+			}
+			removed = true
+		}
+
+		if !removed {
 			fmt.Println("Cache directory does not exist")
 			return
 		}
+		fmt.Println("Cache cleaned successfully")
+	},
+}
 
-		// キャッシュディレクトリを削除
-		fmt.Printf("Removing cache directory: %s\n", CacheDir)
-		if err := os.RemoveAll(CacheDir); err != nil {
-			fmt.Fprintf(os.Stderr, "Error removing cache directory: %v\n", err)
+var cacheStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the state of the local cache",
+	Run: func(cmd *cobra.Command, args []string) {
+		sources, err := getSources()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving template sources: %v\n", err)
 			os.Exit(1)
 		}
 
-		fmt.Println("Cache cleaned successfully")
+		for _, src := range sources {
+			fmt.Printf("%s (priority %d)\n", src.Name, src.Priority)
+			fmt.Printf("  Path:   %s\n", src.Dir)
+
+			switch {
+			case src.Local:
+				fmt.Println("  Type:   local directory")
+			case isSnapshot(src.Dir):
+				fmt.Println("  Type:   embedded snapshot")
+			default:
+				fmt.Printf("  Type:   git (%s)\n", src.URL)
+			}
+			if src.Ref != "" {
+				fmt.Printf("  Ref:    %s\n", src.Ref)
+			}
+
+			if src.Local {
+				continue
+			}
+			if _, err := os.Stat(src.Dir); os.IsNotExist(err) {
+				fmt.Println("  Commit: (not cached)")
+				continue
+			}
+			commit, err := cacheCommit(src.Dir)
+			if err != nil {
+				fmt.Printf("  Commit: (unknown: %v)\n", err)
+				continue
+			}
+			fmt.Printf("  Commit: %s\n", commit)
+		}
+
+		// 組み込みスナップショットの情報
+		commit, err := snapshotCommit()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading embedded snapshot: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Embedded snapshot commit: %s\n", commit)
 	},
 }
 
 func init() {
+	cacheUpdateCmd.Flags().StringVar(&pinRef, "ref", "", "Pin the github/gitignore cache to a commit SHA, tag or branch")
 	cacheCmd.AddCommand(cacheUpdateCmd)
 	cacheCmd.AddCommand(cacheCleanCmd)
+	cacheCmd.AddCommand(cacheStatusCmd)
 	RootCmd.AddCommand(cacheCmd)
 }
@@ -5,40 +5,111 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 )
 
 // cloneCache clones the github/gitignore repository to the cache directory
 func cloneCache(cacheDir string) error {
+	return cloneRepository(defaultSourceURL, cacheDir)
+}
+
+// cloneRepository clones the git repository at url to dir
+func cloneRepository(url, dir string) error {
 	// 親ディレクトリを作成
-	if err := os.MkdirAll(filepath.Dir(cacheDir), 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
 		return err
 	}
 
-	cmd := exec.Command("git", "clone", "--depth", "1", "https://github.com/github/gitignore", cacheDir)
+	cmd := exec.Command("git", "clone", "--depth", "1", url, dir)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
 	return cmd.Run()
 }
 
+// cloneOrExtract clones url to dir, falling back to the embedded snapshot when allowed.
+// ネットワークや git が使えない環境でも、初回の生成が失敗しないようにします
+func cloneOrExtract(url, dir string, snapshotFallback bool) error {
+	fmt.Printf("Cache not found. Cloning %s...\n", url)
+	err := cloneRepository(url, dir)
+	if err == nil {
+		return nil
+	}
+	if !snapshotFallback {
+		return fmt.Errorf("failed to clone cache: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Failed to clone cache: %v\nUsing the embedded template snapshot instead.\n", err)
+	// クローンに失敗した残骸を取り除いてから展開
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := extractSnapshot(dir); err != nil {
+		return fmt.Errorf("failed to extract snapshot: %w", err)
+	}
+	return nil
+}
+
+// recloneCache replaces the cache in dir with a fresh clone of url
+func recloneCache(url, dir string) error {
+	tmpDir := dir + cloneDirSuffix
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return err
+	}
+	if err := cloneRepository(url, tmpDir); err != nil {
+		os.RemoveAll(tmpDir)
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return os.Rename(tmpDir, dir)
+}
+
 // EnsureCache ensures the cache directory exists and updates it if needed
 // skipUpdateがtrueの場合は更新をスキップ
 func EnsureCache(cacheDir string, skipUpdate bool) error {
+	return ensureRepository(singleSource(cacheDir)[0], skipUpdate)
+}
+
+// ensureRepository ensures the source's cache holds a clone and updates it if needed.
+// Ref が指定されている場合は最新に追従せず、その ref をチェックアウトします
+func ensureRepository(src Source, skipUpdate bool) error {
 	// キャッシュディレクトリが存在しない場合はクローン
-	if _, err := os.Stat(cacheDir); os.IsNotExist(err) {
-		fmt.Println("Cache not found. Cloning github/gitignore repository...")
-		if err := cloneCache(cacheDir); err != nil {
-			return fmt.Errorf("failed to clone cache: %w", err)
+	if _, err := os.Stat(src.Dir); os.IsNotExist(err) {
+		if err := cloneOrExtract(src.URL, src.Dir, src.snapshotFallback()); err != nil {
+			return err
+		}
+		if src.Ref != "" {
+			return checkoutRef(src.Dir, src.Ref, false)
 		}
 		return nil
 	}
 
 	// キャッシュが存在する場合は更新を確認
-	if skipUpdate {
+	switch {
+	case src.Ref != "":
+		if err := replacePinnedSnapshot(src); err != nil {
+			return err
+		}
+		return checkoutRef(src.Dir, src.Ref, skipUpdate)
+	case skipUpdate:
 		fmt.Println("Skipping cache update...")
-	} else {
+	case isSnapshot(src.Dir):
+		// スナップショットから作ったキャッシュは git リポジトリではないので、クローンし直す
+		fmt.Println("Replacing snapshot cache with a fresh clone...")
+		if err := recloneCache(src.URL, src.Dir); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to update cache: %v\nKeeping the embedded template snapshot.\n", err)
+		}
+	case pinnedRef(src.Dir) != "":
+		// 固定が解除されたキャッシュは最新をクローンし直す
+		fmt.Println("Cache is no longer pinned. Cloning the latest templates...")
+		if err := recloneCache(src.URL, src.Dir); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to update cache: %v\nSkipping cache update.\n", err)
+		}
+	default:
 		fmt.Println("Updating cache...")
-		if err := updateCache(cacheDir); err != nil {
+		if err := updateCache(src.Dir); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to update cache: %v\nSkipping cache update.\n", err)
 			// 更新失敗はエラーとせず続行
 		}
@@ -46,3 +117,188 @@ func EnsureCache(cacheDir string, skipUpdate bool) error {
 
 	return nil
 }
+
+// replacePinnedSnapshot replaces a snapshot cache with a clone when the source is pinned.
+// スナップショットは ref をチェックアウトできないため、固定したコミットと一致しない限り
+// クローンし直し、失敗した場合は固定されていない内容を使わないようエラーとします
+func replacePinnedSnapshot(src Source) error {
+	if !isSnapshot(src.Dir) {
+		return nil
+	}
+	if commit, err := cacheCommit(src.Dir); err == nil && isCommitPrefix(src.Ref, commit) {
+		return nil
+	}
+	fmt.Printf("Replacing snapshot cache with a clone pinned to %s...\n", src.Ref)
+	if err := recloneCache(src.URL, src.Dir); err != nil {
+		return fmt.Errorf("cache holds the embedded snapshot and cloning for %s failed: %w", src.Ref, err)
+	}
+	return nil
+}
+
+// pinnedRefFile は固定中の ref を記録する .git 内のファイル名です
+const pinnedRefFile = "mushi-pinned-ref"
+
+// pinnedRef returns the ref the cache in dir was last pinned to, or ""
+func pinnedRef(dir string) string {
+	content, err := os.ReadFile(filepath.Join(dir, ".git", pinnedRefFile))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+// checkoutRef pins the cache in dir to ref, fetching only that commit when needed.
+// コミットハッシュで固定済みの場合や、skipUpdate で同じ ref に固定済みの場合はネットワークを使いません
+func checkoutRef(dir, ref string, skipUpdate bool) error {
+	if head, err := cacheCommit(dir); err == nil {
+		if isCommitPrefix(ref, head) || (skipUpdate && pinnedRef(dir) == ref) {
+			fmt.Printf("Cache is pinned to %s\n", ref)
+			return nil
+		}
+	}
+
+	// 手元に既にあるコミットはそのままチェックアウトする（短縮ハッシュは fetch できないため）
+	target := "FETCH_HEAD"
+	if isHex(ref) && exec.Command("git", "-C", dir, "cat-file", "-e", ref+"^{commit}").Run() == nil {
+		target = ref
+	} else {
+		fmt.Printf("Fetching %s...\n", ref)
+		if err := runGit(dir, "fetch", "--depth", "1", "origin", ref); err != nil {
+			return fmt.Errorf("failed to fetch %s: %w", ref, err)
+		}
+	}
+	if err := runGit(dir, "checkout", "--quiet", "--detach", target); err != nil {
+		return fmt.Errorf("failed to check out %s: %w", ref, err)
+	}
+	return os.WriteFile(filepath.Join(dir, ".git", pinnedRefFile), []byte(ref+"\n"), 0644)
+}
+
+// isCommitPrefix reports whether ref is an abbreviated or full hash of commit
+func isCommitPrefix(ref, commit string) bool {
+	if len(ref) < 7 || len(ref) > len(commit) || !isHex(ref) {
+		return false
+	}
+	return strings.HasPrefix(commit, strings.ToLower(ref))
+}
+
+// isHex reports whether s consists only of hexadecimal digits
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// isFullCommit reports whether s is a full 40-character commit SHA
+func isFullCommit(s string) bool {
+	return len(s) == 40 && isHex(s)
+}
+
+// runGit runs git in dir, forwarding its output
+func runGit(dir string, args ...string) error {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+// cacheCommit returns the commit the cache in dir currently points at.
+// スナップショットから作ったキャッシュの場合はスナップショットのコミットを返します
+func cacheCommit(dir string) (string, error) {
+	if isSnapshot(dir) {
+		content, err := os.ReadFile(filepath.Join(dir, snapshotMarker))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(content)), nil
+	}
+
+	output, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// lastModifiedCommits maps each file in the cache in dir to the newest commit
+// that changed it. キャッシュは --depth 1 でクローンしているため、まず履歴を取得します。
+// 取得できなかった場合、浅いクローンの境界のコミットはすべてのファイルを変更したように
+// 見えるため、境界に属するファイルと、履歴を持たないスナップショットのファイルは対応表に含めません
+func lastModifiedCommits(dir string) (map[string]string, error) {
+	commits := make(map[string]string)
+	if isSnapshot(dir) {
+		return commits, nil
+	}
+
+	boundary, err := shallowCommits(dir)
+	if err != nil {
+		return nil, err
+	}
+	if boundary != nil {
+		if err := unshallowRepository(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to fetch the history of %s, older templates have no commit: %v\n", dir, err)
+		} else {
+			boundary = nil
+		}
+	}
+
+	output, err := exec.Command("git", "-C", dir, "-c", "core.quotePath=false", "log", "--format=%x00%H", "--name-only").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	// 新しいコミットから順に出力されるため、最初に現れたコミットを採用する
+	var commit string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(output), "\n") {
+		switch {
+		case strings.HasPrefix(line, "\x00"):
+			commit = line[1:]
+		case line != "" && !seen[line]:
+			seen[line] = true
+			if !boundary[commit] {
+				commits[line] = commit
+			}
+		}
+	}
+	return commits, nil
+}
+
+// unshallowRepository fetches the full history of the shallow clone in dir.
+// コミットの一覧だけが必要なので、対応しているサーバーからはファイルの中身を除いて取得します
+func unshallowRepository(dir string) error {
+	fmt.Fprintf(os.Stderr, "Fetching the history of %s...\n", dir)
+	output, err := exec.Command("git", "-C", dir, "fetch", "--quiet", "--unshallow", "--filter=blob:none", "origin").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// shallowCommits returns the boundary commits of a shallow clone, or nil for a full one
+func shallowCommits(dir string) (map[string]bool, error) {
+	output, err := exec.Command("git", "-C", dir, "rev-parse", "--is-shallow-repository").Output()
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(string(output)) != "true" {
+		return nil, nil
+	}
+
+	path, err := exec.Command("git", "-C", dir, "rev-parse", "--path-format=absolute", "--git-path", "shallow").Output()
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(strings.TrimSpace(string(path)))
+	if err != nil {
+		return nil, err
+	}
+	boundary := make(map[string]bool)
+	for _, line := range strings.Fields(string(content)) {
+		boundary[line] = true
+	}
+	return boundary, nil
+}
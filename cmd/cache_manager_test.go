@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -49,3 +50,136 @@ func TestEnsureCache(t *testing.T) {
 		}
 	})
 }
+
+func TestEnsureRepositoryRef(t *testing.T) {
+	tmpDir := t.TempDir()
+	cacheDir := filepath.Join(tmpDir, "cache")
+
+	// テスト用のリポジトリを作成（v1 タグの後に2つ目のコミット）
+	repo := newTestRepo(t)
+	repo.write("Go.gitignore", "v1\n")
+	repo.commit("v1")
+	repo.git("tag", "v1")
+	repo.write("Go.gitignore", "v2\n")
+	second := repo.commit("v2")
+
+	assertTemplate := func(expected string) {
+		t.Helper()
+		content, err := os.ReadFile(filepath.Join(cacheDir, "Go.gitignore"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != expected {
+			t.Errorf("expected template %q, got %q", expected, content)
+		}
+	}
+
+	src := Source{Name: "test", URL: repo.dir, Dir: cacheDir}
+
+	t.Run("clones and pins to a tag", func(t *testing.T) {
+		src.Ref = "v1"
+		if err := ensureRepository(src, false); err != nil {
+			t.Fatalf("ensureRepository failed: %v", err)
+		}
+		assertTemplate("v1\n")
+		if pinnedRef(cacheDir) != "v1" {
+			t.Errorf("expected pinned ref v1, got %q", pinnedRef(cacheDir))
+		}
+	})
+
+	t.Run("moves to an abbreviated commit", func(t *testing.T) {
+		src.Ref = second[:10]
+		if err := ensureRepository(src, true); err != nil {
+			t.Fatalf("ensureRepository failed: %v", err)
+		}
+		assertTemplate("v2\n")
+	})
+
+	t.Run("unknown ref is an error", func(t *testing.T) {
+		src.Ref = "no-such-tag"
+		if err := ensureRepository(src, false); err == nil {
+			t.Error("expected error for unknown ref")
+		}
+	})
+
+	// スナップショットのキャッシュは固定した ref のクローンに置き換える
+	for _, skipUpdate := range []bool{true, false} {
+		t.Run(fmt.Sprintf("replaces a snapshot cache (skipUpdate=%v)", skipUpdate), func(t *testing.T) {
+			if err := os.RemoveAll(cacheDir); err != nil {
+				t.Fatal(err)
+			}
+			if err := extractSnapshot(cacheDir); err != nil {
+				t.Fatal(err)
+			}
+			src.Ref = "v1"
+			if err := ensureRepository(src, skipUpdate); err != nil {
+				t.Fatalf("ensureRepository failed: %v", err)
+			}
+			assertTemplate("v1\n")
+			if isSnapshot(cacheDir) {
+				t.Error("cache should no longer be a snapshot")
+			}
+		})
+	}
+
+	t.Run("EnsureCloned replaces a snapshot cache", func(t *testing.T) {
+		if err := os.RemoveAll(cacheDir); err != nil {
+			t.Fatal(err)
+		}
+		if err := extractSnapshot(cacheDir); err != nil {
+			t.Fatal(err)
+		}
+		src.Ref = "v1"
+		if err := (Sources{src}).EnsureCloned(); err != nil {
+			t.Fatalf("EnsureCloned failed: %v", err)
+		}
+		assertTemplate("v1\n")
+	})
+
+	t.Run("pinned snapshot cache is an error when cloning fails", func(t *testing.T) {
+		if err := os.RemoveAll(cacheDir); err != nil {
+			t.Fatal(err)
+		}
+		if err := extractSnapshot(cacheDir); err != nil {
+			t.Fatal(err)
+		}
+		broken := Source{Name: "test", URL: filepath.Join(tmpDir, "missing"), Dir: cacheDir, Ref: "v1"}
+		if err := ensureRepository(broken, true); err == nil {
+			t.Error("expected error instead of using the snapshot")
+		}
+		if err := (Sources{broken}).EnsureCloned(); err == nil {
+			t.Error("expected error from EnsureCloned instead of using the snapshot")
+		}
+	})
+
+	t.Run("unpinning clones the latest templates", func(t *testing.T) {
+		src.Ref = ""
+		if err := ensureRepository(src, false); err != nil {
+			t.Fatalf("ensureRepository failed: %v", err)
+		}
+		assertTemplate("v2\n")
+		if pinnedRef(cacheDir) != "" {
+			t.Errorf("expected no pinned ref, got %q", pinnedRef(cacheDir))
+		}
+	})
+}
+
+func TestIsCommitPrefix(t *testing.T) {
+	commit := "0123456789abcdef0123456789abcdef01234567"
+	tests := []struct {
+		ref      string
+		expected bool
+	}{
+		{ref: commit, expected: true},
+		{ref: "0123456", expected: true},
+		{ref: "0123456789ABCDEF", expected: true},
+		{ref: "012345", expected: false},
+		{ref: "main", expected: false},
+		{ref: "1234567", expected: false},
+	}
+	for _, tt := range tests {
+		if got := isCommitPrefix(tt.ref, commit); got != tt.expected {
+			t.Errorf("isCommitPrefix(%q) = %v, expected %v", tt.ref, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// テンプレートの分類
+const (
+	categoryRoot      = "root"
+	categoryGlobal    = "global"
+	categoryCommunity = "community"
+)
+
+// templateGroup はある分類に属するテンプレートの一覧です
+type templateGroup struct {
+	Category string
+	Names    []string
+}
+
+// Label returns the heading shown for the group
+func (g templateGroup) Label() string {
+	switch g.Category {
+	case categoryRoot:
+		return "Root"
+	case categoryGlobal:
+		return "Global/"
+	}
+	return g.Category + "/"
+}
+
+// splitSourcePrefix splits "source:Name" into the source and the template name
+func splitSourcePrefix(name string) (source, template string) {
+	if s, t, ok := strings.Cut(name, ":"); ok {
+		return s, t
+	}
+	return "", name
+}
+
+// templateCategory returns the category of a template name.
+// 最上位のテンプレートは "root"、それ以外は最初のディレクトリ名を小文字にしたものです
+func templateCategory(name string) string {
+	_, template := splitSourcePrefix(name)
+	dir, _, ok := strings.Cut(template, "/")
+	if !ok {
+		return categoryRoot
+	}
+	return strings.ToLower(dir)
+}
+
+// groupTemplates groups names by category, ordering root, Global and community first
+func groupTemplates(names []string) []templateGroup {
+	index := make(map[string]int)
+	var groups []templateGroup
+	for _, name := range names {
+		category := templateCategory(name)
+		i, ok := index[category]
+		if !ok {
+			i = len(groups)
+			index[category] = i
+			groups = append(groups, templateGroup{Category: category})
+		}
+		groups[i].Names = append(groups[i].Names, name)
+	}
+
+	rank := func(category string) int {
+		switch category {
+		case categoryRoot:
+			return 0
+		case categoryGlobal:
+			return 1
+		case categoryCommunity:
+			return 2
+		}
+		return 3
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		ri, rj := rank(groups[i].Category), rank(groups[j].Category)
+		if ri != rj {
+			return ri < rj
+		}
+		return groups[i].Category < groups[j].Category
+	})
+	return groups
+}
+
+// filterCategory keeps the names in category, compared case-insensitively
+func filterCategory(names []string, category string) ([]string, error) {
+	category = strings.ToLower(category)
+	var filtered []string
+	var categories []string
+	for _, g := range groupTemplates(names) {
+		categories = append(categories, g.Category)
+		if g.Category == category {
+			filtered = g.Names
+		}
+	}
+	if filtered == nil {
+		return nil, fmt.Errorf("unknown category %s (available: %s)", category, strings.Join(categories, ", "))
+	}
+	return filtered, nil
+}
+
+// treeNode はツリー表示の1つの節です
+type treeNode struct {
+	name     string
+	children []*treeNode
+}
+
+// child returns the child named name, creating it if needed
+func (n *treeNode) child(name string) *treeNode {
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+	}
+	c := &treeNode{name: name}
+	n.children = append(n.children, c)
+	return c
+}
+
+// printTemplateTree writes the templates grouped by category as a tree
+func printTemplateTree(w io.Writer, names []string) {
+	for _, g := range groupTemplates(names) {
+		fmt.Fprintf(w, "%s (%d)\n", g.Label(), len(g.Names))
+
+		// 分類のディレクトリより下のパスで木を作る
+		root := &treeNode{}
+		for _, name := range g.Names {
+			source, template := splitSourcePrefix(name)
+			parts := strings.Split(template, "/")
+			if g.Category != categoryRoot {
+				parts = parts[1:]
+			}
+			node := root
+			for i, part := range parts {
+				if i == len(parts)-1 && source != "" {
+					part += " (" + source + ")"
+				}
+				node = node.child(part)
+			}
+		}
+		printTreeNodes(w, root.children, "")
+	}
+}
+
+// printTreeNodes writes nodes with box-drawing branches
+func printTreeNodes(w io.Writer, nodes []*treeNode, prefix string) {
+	for i, n := range nodes {
+		branch, indent := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, indent = "└── ", "    "
+		}
+		name := n.name
+		if len(n.children) > 0 {
+			name += "/"
+		}
+		fmt.Fprintf(w, "%s%s%s\n", prefix, branch, name)
+		printTreeNodes(w, n.children, prefix+indent)
+	}
+}
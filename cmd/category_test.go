@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestTemplateCategory(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "Go", expected: categoryRoot},
+		{name: "Global/Vim", expected: categoryGlobal},
+		{name: "community/Python/JupyterNotebooks", expected: categoryCommunity},
+		{name: "github:Global/macOS", expected: categoryGlobal},
+		{name: "company:Go", expected: categoryRoot},
+		{name: "Internal/Tool", expected: "internal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := templateCategory(tt.name); got != tt.expected {
+				t.Errorf("templateCategory(%q) = %q, expected %q", tt.name, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGroupTemplates(t *testing.T) {
+	names := []string{"community/Python/JupyterNotebooks", "Go", "Internal/Tool", "Global/Vim", "Node", "Global/macOS"}
+	groups := groupTemplates(names)
+
+	expected := []templateGroup{
+		{Category: categoryRoot, Names: []string{"Go", "Node"}},
+		{Category: categoryGlobal, Names: []string{"Global/Vim", "Global/macOS"}},
+		{Category: categoryCommunity, Names: []string{"community/Python/JupyterNotebooks"}},
+		{Category: "internal", Names: []string{"Internal/Tool"}},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("groupTemplates() = %+v, expected %+v", groups, expected)
+	}
+}
+
+func TestFilterCategory(t *testing.T) {
+	names := []string{"Go", "Global/Vim", "community/Python/JupyterNotebooks"}
+
+	filtered, err := filterCategory(names, "Global")
+	if err != nil {
+		t.Fatalf("filterCategory failed: %v", err)
+	}
+	if !reflect.DeepEqual(filtered, []string{"Global/Vim"}) {
+		t.Errorf("filterCategory() = %v", filtered)
+	}
+
+	if _, err := filterCategory(names, "unknown"); err == nil {
+		t.Errorf("filterCategory() with an unknown category succeeded")
+	}
+}
+
+func TestPrintTemplateTree(t *testing.T) {
+	names := []string{
+		"Go",
+		"Node",
+		"Global/Vim",
+		"community/Python/JupyterNotebooks",
+		"community/Python/Nikola",
+		"community/Golang/Hugo",
+		"company:Go",
+	}
+
+	var buf bytes.Buffer
+	printTemplateTree(&buf, names)
+
+	expected := `Root (3)
+├── Go
+├── Node
+└── Go (company)
+Global/ (1)
+└── Vim
+community/ (3)
+├── Python/
+│   ├── JupyterNotebooks
+│   └── Nikola
+└── Golang/
+    └── Hugo
+`
+	if buf.String() != expected {
+		t.Errorf("printTemplateTree() =\n%s\nexpected\n%s", buf.String(), expected)
+	}
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// checkIgnorePath は path を .gitignore の場所からの相対パスに変換します
+func checkIgnorePath(base, path string) (string, bool) {
+	isDir := strings.HasSuffix(path, "/")
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		isDir = true
+	}
+
+	rel := path
+	if abs, err := filepath.Abs(path); err == nil {
+		if r, err := filepath.Rel(base, abs); err == nil {
+			rel = r
+		}
+	}
+	return filepath.ToSlash(rel), isDir
+}
+
+var checkIgnoreCmd = &cobra.Command{
+	Use:   "check-ignore <path>...",
+	Short: "Check whether paths would be ignored by the .gitignore file",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// "-" が指定されたら標準入力から読み込む（書き込み前の生成結果を確認するため）
+		var content []byte
+		var err error
+		source := outputPath
+		base := filepath.Dir(outputPath)
+		if outputPath == "-" {
+			content, err = io.ReadAll(os.Stdin)
+			source = "<stdin>"
+			base = "."
+		} else {
+			content, err = os.ReadFile(outputPath)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", source, err)
+			os.Exit(1)
+		}
+		if base, err = filepath.Abs(base); err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving %s: %v\n", source, err)
+			os.Exit(1)
+		}
+
+		matcher := NewMatcher(content)
+		anyIgnored := false
+		for _, arg := range args {
+			path, isDir := checkIgnorePath(base, arg)
+			if strings.HasPrefix(path, "../") || path == ".." {
+				fmt.Fprintf(os.Stderr, "Warning: %s is outside the directory of %s\n", arg, source)
+			}
+			rule, ignored := matcher.Match(path, isDir)
+			if ignored {
+				anyIgnored = true
+			}
+
+			// git check-ignore と同じ書式で出力
+			switch {
+			case checkIgnoreVerbose && rule != nil:
+				fmt.Printf("%s:%d:%s\t%s\n", source, rule.Line, rule.Pattern, arg)
+			case checkIgnoreVerbose && checkIgnoreNonMatching && rule == nil:
+				fmt.Printf("::\t%s\n", arg)
+			case ignored:
+				fmt.Println(arg)
+			}
+		}
+
+		if !anyIgnored {
+			os.Exit(1)
+		}
+	},
+}
+
+// check-ignoreのみのオプションを記述
+var (
+	checkIgnoreVerbose     bool
+	checkIgnoreNonMatching bool
+)
+
+func init() {
+	checkIgnoreCmd.Flags().BoolVarP(&checkIgnoreVerbose, "verbose", "v", false, "Show the matching source, line and pattern for each path")
+	checkIgnoreCmd.Flags().BoolVarP(&checkIgnoreNonMatching, "non-matching", "n", false, "Also show paths that match no pattern (requires -v)")
+	checkIgnoreCmd.Flags().StringVarP(&outputPath, "path", "p", ".gitignore", "Path to the .gitignore file, or - to read from stdin")
+	RootCmd.AddCommand(checkIgnoreCmd)
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// Section は生成される .gitignore 内の1つのテンプレート区画を表します
+type Section struct {
+	Name    string
+	Content []byte
+}
+
+// loadTemplates reads every named template from the sources.
+// 1つでも見つからないテンプレートがあれば、何も返さずにエラーとします
+func loadTemplates(sources Sources, names []string) ([]Section, error) {
+	sections := make([]Section, 0, len(names))
+	for _, name := range names {
+		templatePath, err := sources.Path(name)
+		if err != nil {
+			return nil, err
+		}
+		content, err := os.ReadFile(templatePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %w", name, err)
+		}
+		if content, err = renderTemplate(sources, name, templatePath, content); err != nil {
+			return nil, err
+		}
+		sections = append(sections, Section{Name: name, Content: content})
+	}
+	return sections, nil
+}
+
+// renderTemplate expands the imports of a template read from path
+func renderTemplate(sources Sources, name, path string, content []byte) ([]byte, error) {
+	resolved, err := expandImports(content, sources, false, templateFrame(sources, name, path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve imports in template %s: %w", name, err)
+	}
+	// 展開で末尾に付く改行を取り除き、元のファイルと同じ形に戻す
+	return resolved[:len(resolved)-1], nil
+}
+
+// templateFrame returns the import frame of a template.
+// ファイルの取り込みはローカルのソースのテンプレートにだけ許します
+func templateFrame(sources Sources, name, path string) importFrame {
+	src, _, err := sources.Lookup(name)
+	return importFrame{name: name, path: path, allowFiles: err == nil && src.Local}
+}
+
+// loadCommon reads common.gitignore and resolves its imports
+func loadCommon(configDir string, sources Sources) ([]byte, error) {
+	// 共通無視ファイルの存在確認と作成
+	commonIgnorePath, err := EnsureCommonIgnore(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to manage common.gitignore: %w", err)
+	}
+
+	// 共通無視ファイルの内容を読み込む
+	commonContent, err := os.ReadFile(commonIgnorePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read common.gitignore: %w", err)
+	}
+	if len(commonContent) == 0 {
+		return nil, nil
+	}
+
+	// インポートを解決
+	resolved, err := expandImports(commonContent, sources, false, importFrame{name: "common.gitignore", path: commonIgnorePath, allowFiles: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve imports in common.gitignore: %w", err)
+	}
+
+	// ResolveImports が末尾に付ける余分な改行を取り除く
+	resolved = bytes.TrimRight(resolved, "\n")
+	if len(resolved) == 0 {
+		return nil, nil
+	}
+	return append(resolved, '\n'), nil
+}
+
+// composeDocument builds a new document from common.gitignore and the named templates
+func composeDocument(sources Sources, configDir string, names []string, dedupe bool) (*Document, []Section, error) {
+	// すべてのテンプレートを読み込む（1つでも欠けていれば書き込む前に中断）
+	sections, err := loadTemplates(sources, names)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 共通無視ファイルを読み込み、インポートを解決
+	common, err := loadCommon(configDir, sources)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 共通無視ファイルと各テンプレートを管理区画として結合
+	doc := NewDocument(common, sections)
+	if dedupe {
+		doc.Dedupe()
+	}
+	return doc, sections, nil
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadTemplates(t *testing.T) {
+	cacheDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(cacheDir, "Go.gitignore"), []byte("bin/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cacheDir, "Node.gitignore"), []byte("node_modules/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("reads templates in order", func(t *testing.T) {
+		sections, err := loadTemplates(singleSource(cacheDir), []string{"Node", "Go"})
+		if err != nil {
+			t.Fatalf("loadTemplates failed: %v", err)
+		}
+		if len(sections) != 2 || sections[0].Name != "Node" || sections[1].Name != "Go" {
+			t.Errorf("unexpected sections: %+v", sections)
+		}
+	})
+
+	t.Run("fails when any template is missing", func(t *testing.T) {
+		sections, err := loadTemplates(singleSource(cacheDir), []string{"Go", "Terraform"})
+		if err == nil {
+			t.Fatal("expected error for missing template")
+		}
+		if sections != nil {
+			t.Errorf("expected no sections on error, got %+v", sections)
+		}
+	})
+}
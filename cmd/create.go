@@ -3,20 +3,19 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 
 	"github.com/spf13/cobra"
 )
 
 var createCmd = &cobra.Command{
-	Use:   "create [template]",
-	Short: "Generate .gitignore from a template",
+	Use:   "create [template...]",
+	Short: "Generate .gitignore from one or more templates",
 	Args:  cobra.ArbitraryArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		// キャッシュディレクトリのパスを解決
-		cacheDir, err := getCacheDir()
+		// テンプレートの取得元を解決
+		sources, err := getSources()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error getting cache directory: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error resolving template sources: %v\n", err)
 			os.Exit(1)
 		}
 
@@ -27,81 +26,75 @@ var createCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		var template string
+		var templates []string
 		if interactive {
 			// インタラクティブモード
-			template, err = runInteractiveSelector(cacheDir)
+			// 確定前に生成結果を表示
+			confirm := func(names []string) (string, error) {
+				doc, _, err := composeDocument(sources, configDir, names, !noDedupe)
+				if err != nil {
+					return "", err
+				}
+				return string(doc.Bytes()), nil
+			}
+			selected, err := runInteractiveSelector(sources, category, confirm)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error in interactive mode: %v\n", err)
 				os.Exit(1)
 			}
-			if template == "" {
+			if len(selected) == 0 {
 				fmt.Println("No template selected")
 				return
 			}
+			templates = selected
 		} else {
 			// 非インタラクティブモード
-			if len(args) < 1 {
+			if len(args) < 1 && !detect {
 				fmt.Fprintln(os.Stderr, "Error: template name is required")
 				os.Exit(1)
 			}
-			template = args[0]
+			templates = args
 		}
 
 		// キャッシュの存在確認と更新
 		skipUpdate := noUpdate || config.NoUpdate
-		if err := EnsureCache(cacheDir, skipUpdate); err != nil {
+		if err := sources.Ensure(skipUpdate); err != nil {
 			fmt.Fprintf(os.Stderr, "Error managing cache: %v\n", err)
 			os.Exit(1)
 		}
 
-		// 共通無視ファイルの存在確認と作成
-		commonIgnorePath, err := EnsureCommonIgnore(configDir)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error managing common.gitignore: %v\n", err)
-			os.Exit(1)
-		}
-
-		// テンプレートファイルのパスを構築
-		templatePath := filepath.Join(cacheDir, template+".gitignore")
-
-		// テンプレートファイルの内容を読み込む
-		templateContent, err := os.ReadFile(templatePath)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading template file %s: %v\n", templatePath, err)
-			os.Exit(1)
-		}
-
-		// 共通無視ファイルの内容を読み込む
-		commonContent, err := os.ReadFile(commonIgnorePath)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading common.gitignore file: %v\n", err)
-			os.Exit(1)
-		}
-
-		// インポートを解決
-		var resolvedCommon []byte
-		if len(commonContent) > 0 {
-			resolvedCommon, err = ResolveImports(commonContent, cacheDir)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error resolving imports in common.gitignore: %v\n", err)
+		// --detect が指定されたらプロジェクトのファイルから検出したテンプレートを追加
+		if detect {
+			templates = appendDetectedTemplates(templates, sources)
+			if len(templates) == 0 {
+				fmt.Fprintln(os.Stderr, "Error: no templates detected")
 				os.Exit(1)
 			}
 		}
 
-		// 両方の内容を結合
-		var finalContent []byte
-		if len(resolvedCommon) > 0 {
-			finalContent = append(finalContent, resolvedCommon...)
-			finalContent = append(finalContent, '\n')
+		// 共通無視ファイルと各テンプレートを管理区画として結合
+		doc, sections, err := composeDocument(sources, configDir, templates, !noDedupe)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
 		}
-		finalContent = append(finalContent, templateContent...)
+		finalContent := doc.Bytes()
 
 		// --print が指定されたら標準出力に表示
 		if print {
 			os.Stdout.Write(finalContent)
 			return
 		}
+
+		// --diff / --dry-run が指定されたら差分のみ表示
+		if showDiff || dryRun {
+			previewDiff(outputPath, finalContent, showDiff)
+			return
+		}
+
+		// 追跡中のファイルが新たに無視される場合は警告（--force が無ければ中断）
+		checkTrackedFiles(outputPath, finalContent, force)
+
 		// 既に出力ファイルが存在するか確認
 		if _, err := os.Stat(outputPath); err == nil {
 			if !force {
@@ -119,20 +112,34 @@ var createCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
+		// mushi.lock に生成内容を記録
+		if !noLock {
+			if err := recordLock(outputPath, finalContent, doc, sources, configDir, sections, !noDedupe); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to update %s: %v\n", lockFileName, err)
+			}
+		}
+
 		fmt.Printf("✨️ Successfully generated %s\n", outputPath)
 	},
 }
 
 // createのみのオプションを記述
 var (
-	force bool
+	detect bool
 )
 
 func init() {
-	createCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Interactively select a template")
-	createCmd.Flags().BoolVarP(&force, "force", "f", false, "Force overwrite existing .gitignore file")
+	createCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Interactively select templates")
+	createCmd.Flags().StringVar(&category, "category", "", "Only offer templates in this category in interactive mode (root, global, community)")
+	createCmd.Flags().BoolVarP(&force, "force", "f", false, "Force overwrite existing .gitignore file, even if it would ignore tracked files")
+	createCmd.Flags().BoolVar(&detect, "detect", false, "Add templates detected from the files in the current directory")
 	createCmd.Flags().BoolVar(&noUpdate, "no-update", false, "Skip updating the local cache")
+	createCmd.Flags().StringVar(&pinRef, "ref", "", "Pin the github/gitignore cache to a commit SHA, tag or branch")
+	createCmd.Flags().BoolVar(&noDedupe, "no-dedupe", false, "Keep duplicate patterns across sections")
 	createCmd.Flags().BoolVar(&print, "print", false, "Print the result to stdout instead of writing to .gitignore")
+	createCmd.Flags().BoolVar(&showDiff, "diff", false, "Print a unified diff instead of writing, and exit with status 1 if the file would change")
+	createCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print a unified diff instead of writing")
+	createCmd.Flags().BoolVar(&noLock, "no-lock", false, "Do not record the result in mushi.lock")
 	createCmd.Flags().StringVarP(&outputPath, "path", "p", ".gitignore", "Path to output file (default: .gitignore)")
 	RootCmd.AddCommand(createCmd)
 }
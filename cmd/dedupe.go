@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+)
+
+// normalizePattern returns a canonical key for a gitignore pattern line.
+// コメント行と空行の場合は ok が false になります
+func normalizePattern(line string) (key string, negated bool, ok bool) {
+	p := strings.TrimRight(line, "\r\n")
+	if strings.TrimSpace(p) == "" || strings.HasPrefix(p, "#") {
+		return "", false, false
+	}
+
+	// エスケープされていない末尾の空白は git に無視される（タブはパターンの一部として残る）
+	for strings.HasSuffix(p, " ") && !strings.HasSuffix(p, "\\ ") {
+		p = p[:len(p)-1]
+	}
+
+	if strings.HasPrefix(p, "!") {
+		negated = true
+		p = p[1:]
+	}
+
+	// 途中にスラッシュを含むパターンは先頭の "/" の有無に関わらず固定される
+	body := strings.TrimSuffix(p, "/")
+	if strings.HasPrefix(body, "/") && strings.Contains(body[1:], "/") {
+		p = p[1:]
+	}
+	// "**/name" はスラッシュを含まない "name" と同じ意味になる
+	if rest := strings.TrimPrefix(p, "**/"); rest != p && !strings.Contains(strings.TrimSuffix(rest, "/"), "/") {
+		p = rest
+	}
+
+	if negated {
+		return "!" + p, true, true
+	}
+	return p, false, true
+}
+
+// Dedupe removes duplicate patterns from the managed sections of the document.
+// 重複行の削除は、最初の出現との間に逆の意味のルール（否定とその対象）が
+// 挟まっていない場合に限ります。これにより後勝ちの評価結果は変わりません。
+// 手書きのテキストは参照のみ行い、変更しません。
+func (d *Document) Dedupe() {
+	d.dedupe("", true)
+}
+
+// dedupeCommon removes patterns from the common section that already appear
+// elsewhere earlier in the document.
+// 共通区画の中の重複は残すため、--no-dedupe でも結果が common.gitignore から決まります
+func (d *Document) dedupeCommon() {
+	d.dedupe(commonSectionName, false)
+}
+
+// dedupe removes duplicate patterns from the named section, or from every
+// managed section when only is empty.
+// self が false の場合、only の区画の行は以前の出現として数えません
+func (d *Document) dedupe(only string, self bool) {
+	seen := make(map[string]int)
+	lastPositive, lastNegative := -1, -1
+	pos := 0
+
+	for i := range d.blocks {
+		b := &d.blocks[i]
+		var kept []byte
+		for _, line := range bytes.SplitAfter(b.content, []byte("\n")) {
+			if len(line) == 0 {
+				continue
+			}
+			key, negated, ok := normalizePattern(string(line))
+			if !ok {
+				kept = append(kept, line...)
+				continue
+			}
+			pos++
+
+			if prev, dup := seen[key]; dup && b.name != "" && (only == "" || b.name == only) {
+				opposite := lastNegative
+				if negated {
+					opposite = lastPositive
+				}
+				if opposite < prev {
+					continue
+				}
+			}
+
+			if self || only == "" || b.name != only {
+				seen[key] = pos
+			}
+			if negated {
+				lastNegative = pos
+			} else {
+				lastPositive = pos
+			}
+			kept = append(kept, line...)
+		}
+		if b.name != "" {
+			b.content = kept
+		}
+	}
+}
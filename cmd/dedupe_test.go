@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestNormalizePattern(t *testing.T) {
+	tests := []struct {
+		line    string
+		key     string
+		negated bool
+		ok      bool
+	}{
+		{line: "*.log", key: "*.log", ok: true},
+		{line: "*.log   \n", key: "*.log", ok: true},
+		{line: "foo\\ ", key: "foo\\ ", ok: true},
+		{line: "foo\t", key: "foo\t", ok: true},
+		{line: "**/node_modules/", key: "node_modules/", ok: true},
+		{line: "**/a/b", key: "**/a/b", ok: true},
+		{line: "/a/b", key: "a/b", ok: true},
+		{line: "/build", key: "/build", ok: true},
+		{line: "!.env.example", key: "!.env.example", negated: true, ok: true},
+		{line: "# comment", ok: false},
+		{line: "   ", ok: false},
+		{line: "\\#file", key: "\\#file", ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			key, negated, ok := normalizePattern(tt.line)
+			if key != tt.key || negated != tt.negated || ok != tt.ok {
+				t.Errorf("normalizePattern(%q) = (%q, %v, %v), expected (%q, %v, %v)",
+					tt.line, key, negated, ok, tt.key, tt.negated, tt.ok)
+			}
+		})
+	}
+}
+
+func TestDocumentDedupe(t *testing.T) {
+	tests := []struct {
+		name     string
+		common   string
+		sections []Section
+		expected string
+	}{
+		{
+			name:   "removes duplicates across sections",
+			common: ".DS_Store\n*.log\n",
+			sections: []Section{
+				{Name: "Go", Content: []byte("# Logs\n*.log\nbin/\n")},
+				{Name: "Node", Content: []byte("**/.DS_Store\nnode_modules/\nbin/  \n")},
+			},
+			expected: "# >>> mushi: common.gitignore\n.DS_Store\n*.log\n# <<< mushi: common.gitignore\n\n" +
+				"# >>> mushi: Go\n# Logs\nbin/\n# <<< mushi: Go\n\n" +
+				"# >>> mushi: Node\nnode_modules/\n# <<< mushi: Node\n",
+		},
+		{
+			name:   "keeps duplicate re-applied after a negation",
+			common: "*.env\n!prod.env\n",
+			sections: []Section{
+				{Name: "Secrets", Content: []byte("*.env\n!prod.env\n")},
+			},
+			expected: "# >>> mushi: common.gitignore\n*.env\n!prod.env\n# <<< mushi: common.gitignore\n\n" +
+				"# >>> mushi: Secrets\n*.env\n!prod.env\n# <<< mushi: Secrets\n",
+		},
+		{
+			name:   "removes duplicate negation with no rule in between",
+			common: "*.env\n!example.env\n",
+			sections: []Section{
+				{Name: "Env", Content: []byte("!example.env\n")},
+			},
+			expected: "# >>> mushi: common.gitignore\n*.env\n!example.env\n# <<< mushi: common.gitignore\n\n" +
+				"# >>> mushi: Env\n# <<< mushi: Env\n",
+		},
+		{
+			name:   "keeps a rule whose earlier copy has a trailing tab",
+			common: "foo\t\n",
+			sections: []Section{
+				{Name: "Foo", Content: []byte("foo\n")},
+			},
+			expected: "# >>> mushi: common.gitignore\nfoo\t\n# <<< mushi: common.gitignore\n\n" +
+				"# >>> mushi: Foo\nfoo\n# <<< mushi: Foo\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := NewDocument([]byte(tt.common), tt.sections)
+			doc.Dedupe()
+			if string(doc.Bytes()) != tt.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", tt.expected, doc.Bytes())
+			}
+		})
+	}
+
+	t.Run("never modifies hand-written text", func(t *testing.T) {
+		input := "*.log\n*.log\n\n# >>> mushi: Go\n*.log\nbin/\n# <<< mushi: Go\n"
+		doc, err := ParseDocument([]byte(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc.Dedupe()
+
+		expected := "*.log\n*.log\n\n# >>> mushi: Go\nbin/\n# <<< mushi: Go\n"
+		if string(doc.Bytes()) != expected {
+			t.Errorf("expected:\n%q\ngot:\n%q", expected, doc.Bytes())
+		}
+	})
+}
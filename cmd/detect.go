@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// DetectRule はプロジェクト内のマーカーファイルとテンプレートの対応です。
+// Pattern はファイル名に対する glob で、末尾が "/" の場合はディレクトリに一致します
+type DetectRule struct {
+	Pattern  string `mapstructure:"pattern" json:"pattern"`
+	Template string `mapstructure:"template" json:"template"`
+}
+
+// Detection は検出されたテンプレートと、その根拠になったパスです
+type Detection struct {
+	Template string `json:"template"`
+	Pattern  string `json:"pattern"`
+	Path     string `json:"path"`
+}
+
+// defaultDetectRules は組み込みの検出ルールです
+var defaultDetectRules = []DetectRule{
+	{Pattern: "go.mod", Template: "Go"},
+	{Pattern: "package.json", Template: "Node"},
+	{Pattern: "Cargo.toml", Template: "Rust"},
+	{Pattern: "*.tf", Template: "Terraform"},
+	{Pattern: "pyproject.toml", Template: "Python"},
+	{Pattern: "requirements.txt", Template: "Python"},
+	{Pattern: "Gemfile", Template: "Ruby"},
+	{Pattern: "pom.xml", Template: "Maven"},
+	{Pattern: "build.gradle", Template: "Gradle"},
+	{Pattern: "build.gradle.kts", Template: "Gradle"},
+	{Pattern: "composer.json", Template: "Composer"},
+	{Pattern: "pubspec.yaml", Template: "Dart"},
+	{Pattern: "CMakeLists.txt", Template: "CMake"},
+	{Pattern: "*.csproj", Template: "VisualStudio"},
+	{Pattern: ".idea/", Template: "Global/JetBrains"},
+	{Pattern: ".vscode/", Template: "Global/VisualStudioCode"},
+}
+
+// skipDetectDirs は検出時に探索しないディレクトリです。
+// 依存物、ビルド成果物、テスト用の fixture はプロジェクト自体の種類を表さないため除外します
+var skipDetectDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+	"target":       true,
+	"dist":         true,
+	".venv":        true,
+	"venv":         true,
+	"__pycache__":  true,
+	"testdata":     true,
+	"fixtures":     true,
+}
+
+// detectRules returns the built-in rules followed by those from config.toml
+func detectRules() []DetectRule {
+	return append(append([]DetectRule{}, defaultDetectRules...), config.Detect...)
+}
+
+// detectTemplates walks root and returns one detection per matching template, in rule order.
+// root の .gitignore で既に無視されているディレクトリには入りません
+func detectTemplates(root string, rules []DetectRule) ([]Detection, error) {
+	var ignore *Matcher
+	content, err := os.ReadFile(filepath.Join(root, ".gitignore"))
+	if err == nil {
+		ignore = NewMatcher(content)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	found := make(map[int]string)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		for i, rule := range rules {
+			if _, ok := found[i]; ok {
+				continue
+			}
+			pattern := strings.TrimSuffix(rule.Pattern, "/")
+			if strings.HasSuffix(rule.Pattern, "/") != d.IsDir() {
+				continue
+			}
+			if matched, _ := filepath.Match(pattern, d.Name()); matched {
+				found[i] = filepath.ToSlash(relPath)
+			}
+		}
+
+		if d.IsDir() && skipDetectDirs[d.Name()] {
+			return filepath.SkipDir
+		}
+		if d.IsDir() && ignore != nil {
+			if _, ignored := ignore.Match(filepath.ToSlash(relPath), true); ignored {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// ルールの順序で並べ、同じテンプレートは1つにまとめる
+	var detections []Detection
+	seen := make(map[string]bool)
+	for i, rule := range rules {
+		path, ok := found[i]
+		if !ok || seen[rule.Template] {
+			continue
+		}
+		seen[rule.Template] = true
+		detections = append(detections, Detection{Template: rule.Template, Pattern: rule.Pattern, Path: path})
+	}
+	return detections, nil
+}
+
+// appendDetectedTemplates adds templates detected in the current directory to templates.
+// キャッシュに存在しないテンプレートは警告を出して読み飛ばします
+func appendDetectedTemplates(templates []string, sources Sources) []string {
+	detections, err := detectTemplates(".", detectRules())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to detect templates: %v\n", err)
+		return templates
+	}
+
+	for _, d := range detections {
+		if slices.Contains(templates, d.Template) {
+			continue
+		}
+		if _, err := sources.Path(d.Template); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: detected template %s (%s) is not in the cache\n", d.Template, d.Path)
+			continue
+		}
+		fmt.Printf("Detected %s (%s)\n", d.Template, d.Path)
+		templates = append(templates, d.Template)
+	}
+	return templates
+}
+
+var detectCmd = &cobra.Command{
+	Use:   "detect [dir]",
+	Short: "Suggest templates based on the files in the project",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		root := "."
+		if len(args) > 0 {
+			root = args[0]
+		}
+
+		detections, err := detectTemplates(root, detectRules())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error scanning %s: %v\n", root, err)
+			os.Exit(1)
+		}
+
+		// --json が指定されたら JSON で出力
+		if detectJSON {
+			if detections == nil {
+				detections = []Detection{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(detections); err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if len(detections) == 0 {
+			fmt.Println("No templates detected")
+			return
+		}
+
+		fmt.Printf("Detected templates (%d):\n", len(detections))
+		for _, d := range detections {
+			fmt.Printf("  %-24s (%s)\n", d.Template, d.Path)
+		}
+	},
+}
+
+// detectのみのオプションを記述
+var (
+	detectJSON bool
+)
+
+func init() {
+	detectCmd.Flags().BoolVar(&detectJSON, "json", false, "Print detected templates as JSON")
+	RootCmd.AddCommand(detectCmd)
+}
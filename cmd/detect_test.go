@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectTemplates(t *testing.T) {
+	root := t.TempDir()
+	files := []string{
+		"go.mod",
+		"web/package.json",
+		"infra/main.tf",
+		"infra/vars.tf",
+		"node_modules/left-pad/package.json",
+		"node_modules/pkg/Cargo.toml",
+		"tools/schema.proto",
+		"internal/testdata/app/Cargo.toml",
+		"build/out/pom.xml",
+		"sub/.venv/lib/pyproject.toml",
+	}
+	for _, file := range files {
+		path := filepath.Join(root, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(root, ".idea"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// 既に無視されているディレクトリ内のマーカーは数えない
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("/build/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules := append(append([]DetectRule{}, defaultDetectRules...), DetectRule{Pattern: "*.proto", Template: "Protobuf"})
+	detections, err := detectTemplates(root, rules)
+	if err != nil {
+		t.Fatalf("detectTemplates failed: %v", err)
+	}
+
+	expected := []Detection{
+		{Template: "Go", Pattern: "go.mod", Path: "go.mod"},
+		{Template: "Node", Pattern: "package.json", Path: "web/package.json"},
+		{Template: "Terraform", Pattern: "*.tf", Path: "infra/main.tf"},
+		{Template: "Global/JetBrains", Pattern: ".idea/", Path: ".idea"},
+		{Template: "Protobuf", Pattern: "*.proto", Path: "tools/schema.proto"},
+	}
+	if len(detections) != len(expected) {
+		t.Fatalf("expected %d detections, got %+v", len(expected), detections)
+	}
+	for i := range expected {
+		if detections[i] != expected[i] {
+			t.Errorf("detection %d: expected %+v, got %+v", i, expected[i], detections[i])
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// diffKind は差分の1行の種類です
+type diffKind int
+
+const (
+	diffEqual diffKind = iota
+	diffInsert
+	diffDelete
+)
+
+// diffOp は差分の1行を表します
+type diffOp struct {
+	Kind diffKind
+	Line string
+}
+
+// splitLines splits content into lines without their trailing newlines
+func splitLines(content []byte) []string {
+	s := strings.TrimSuffix(string(content), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// diffLines computes a shortest edit script from a to b using Myers' algorithm
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+	offset := max
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+search:
+	for d := 0; d <= max; d++ {
+		// 各ステップ開始時点の状態を記録し、後で経路を復元する
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// 終点から始点へ経路を辿る
+	var ops []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{Kind: diffEqual, Line: a[x-1]})
+			x--
+			y--
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			ops = append(ops, diffOp{Kind: diffInsert, Line: b[y-1]})
+			y--
+		} else {
+			ops = append(ops, diffOp{Kind: diffDelete, Line: a[x-1]})
+			x--
+		}
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// countChanges returns the number of added and removed lines in ops
+func countChanges(ops []diffOp) (added, removed int) {
+	for _, op := range ops {
+		switch op.Kind {
+		case diffInsert:
+			added++
+		case diffDelete:
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// unifiedDiffContext は統一差分で変更行の前後に表示する行数です
+const unifiedDiffContext = 3
+
+// noNewlineMarker は末尾に改行の無い最終行の後に統一差分が付ける注記です
+const noNewlineMarker = "\\ No newline at end of file"
+
+// diffInputLines splits content for unifiedDiff.
+// 末尾に改行が無ければ最終行に注記を付け、改行の有無だけの違いも差分になるようにします
+func diffInputLines(content []byte) []string {
+	lines := splitLines(content)
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		lines[len(lines)-1] += "\n" + noNewlineMarker
+	}
+	return lines
+}
+
+// unifiedDiff renders the difference between a and b in unified diff format.
+// 差分が無い場合は空を返します
+func unifiedDiff(a, b []byte, fromName, toName string) []byte {
+	ops := diffLines(diffInputLines(a), diffInputLines(b))
+
+	// 各操作の直前までに消費した a と b の行数
+	aLine := make([]int, len(ops)+1)
+	bLine := make([]int, len(ops)+1)
+	for k, op := range ops {
+		aLine[k+1], bLine[k+1] = aLine[k], bLine[k]
+		if op.Kind != diffInsert {
+			aLine[k+1]++
+		}
+		if op.Kind != diffDelete {
+			bLine[k+1]++
+		}
+	}
+
+	var buf bytes.Buffer
+	i := 0
+	for i < len(ops) {
+		// 次の変更箇所を探す
+		for i < len(ops) && ops[i].Kind == diffEqual {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+		if buf.Len() == 0 {
+			fmt.Fprintf(&buf, "--- %s\n+++ %s\n", fromName, toName)
+		}
+
+		// 間の一致行が少ない変更箇所は1つのハンクにまとめる
+		end := i
+		for {
+			for end < len(ops) && ops[end].Kind != diffEqual {
+				end++
+			}
+			run := 0
+			for end+run < len(ops) && ops[end+run].Kind == diffEqual {
+				run++
+			}
+			if end+run == len(ops) || run > 2*unifiedDiffContext {
+				break
+			}
+			end += run
+		}
+
+		start := max(i-unifiedDiffContext, 0)
+		stop := min(end+unifiedDiffContext, len(ops))
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n",
+			hunkRange(aLine[start], aLine[stop]-aLine[start]),
+			hunkRange(bLine[start], bLine[stop]-bLine[start]))
+		for _, op := range ops[start:stop] {
+			switch op.Kind {
+			case diffEqual:
+				buf.WriteString(" " + op.Line + "\n")
+			case diffInsert:
+				buf.WriteString("+" + op.Line + "\n")
+			case diffDelete:
+				buf.WriteString("-" + op.Line + "\n")
+			}
+		}
+		i = stop
+	}
+	return buf.Bytes()
+}
+
+// hunkRange formats the start,count pair of a hunk header
+func hunkRange(consumed, count int) string {
+	// 行を含まない範囲は直前の行番号で表す
+	if count == 0 {
+		return fmt.Sprintf("%d,0", consumed)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", consumed+1)
+	}
+	return fmt.Sprintf("%d,%d", consumed+1, count)
+}
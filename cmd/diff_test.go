@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected string
+		added    int
+		removed  int
+	}{
+		{name: "identical", a: "a\nb\n", b: "a\nb\n", expected: " a, b"},
+		{name: "both empty", a: "", b: "", expected: ""},
+		{name: "insert only", a: "", b: "a\nb\n", expected: "+a,+b", added: 2},
+		{name: "delete only", a: "a\nb\n", b: "", expected: "-a,-b", removed: 2},
+		{name: "replace middle", a: "a\nb\nc\n", b: "a\nx\nc\n", expected: " a,-b,+x, c", added: 1, removed: 1},
+		{name: "append", a: "a\n", b: "a\nb\n", expected: " a,+b", added: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := diffLines(splitLines([]byte(tt.a)), splitLines([]byte(tt.b)))
+
+			var parts []string
+			for _, op := range ops {
+				prefix := " "
+				switch op.Kind {
+				case diffInsert:
+					prefix = "+"
+				case diffDelete:
+					prefix = "-"
+				}
+				parts = append(parts, prefix+op.Line)
+			}
+			if got := strings.Join(parts, ","); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+
+			added, removed := countChanges(ops)
+			if added != tt.added || removed != tt.removed {
+				t.Errorf("expected +%d -%d, got +%d -%d", tt.added, tt.removed, added, removed)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	t.Run("no changes", func(t *testing.T) {
+		if got := unifiedDiff([]byte("a\nb\n"), []byte("a\nb\n"), "old", "new"); len(got) != 0 {
+			t.Errorf("expected empty diff, got:\n%s", got)
+		}
+	})
+
+	t.Run("single hunk with context", func(t *testing.T) {
+		a := "1\n2\n3\n4\n5\n6\n7\n8\n"
+		b := "1\n2\n3\n4\nx\n6\n7\n8\n"
+		expected := "--- old\n+++ new\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+x\n 6\n 7\n 8\n"
+		if got := unifiedDiff([]byte(a), []byte(b), "old", "new"); string(got) != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+		}
+	})
+
+	t.Run("separate hunks", func(t *testing.T) {
+		a := "a\n1\n2\n3\n4\n5\n6\n7\nb\n"
+		b := "A\n1\n2\n3\n4\n5\n6\n7\nB\n"
+		expected := "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -6,4 +6,4 @@\n 5\n 6\n 7\n-b\n+B\n"
+		if got := unifiedDiff([]byte(a), []byte(b), "old", "new"); string(got) != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+		}
+	})
+
+	t.Run("missing trailing newline", func(t *testing.T) {
+		expected := "--- old\n+++ new\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n"
+		if got := unifiedDiff([]byte("a\nb"), []byte("a\nb\n"), "old", "new"); string(got) != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+		}
+	})
+
+	t.Run("trailing newline removed", func(t *testing.T) {
+		expected := "--- old\n+++ new\n@@ -1 +1 @@\n-a\n+a\n\\ No newline at end of file\n"
+		if got := unifiedDiff([]byte("a\n"), []byte("a"), "old", "new"); string(got) != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+		}
+	})
+
+	t.Run("new file", func(t *testing.T) {
+		expected := "--- /dev/null\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n"
+		if got := unifiedDiff(nil, []byte("a\nb\n"), "/dev/null", "new"); string(got) != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+		}
+	})
+}
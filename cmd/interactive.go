@@ -14,7 +14,10 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -25,6 +28,8 @@ var (
 	titleStyle        = lipgloss.NewStyle().MarginLeft(2)
 	itemStyle         = lipgloss.NewStyle().PaddingLeft(4)
 	selectedItemStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(lipgloss.Color("170"))
+	checkedItemStyle  = lipgloss.NewStyle().PaddingLeft(4).Foreground(lipgloss.Color("42"))
+	groupItemStyle    = lipgloss.NewStyle().PaddingLeft(2).Bold(true)
 	paginationStyle   = list.DefaultStyles().PaginationStyle.PaddingLeft(4)
 	helpStyle         = list.DefaultStyles().HelpStyle.PaddingLeft(4).PaddingBottom(1)
 )
@@ -33,20 +38,69 @@ type item string
 
 func (i item) FilterValue() string { return string(i) }
 
-type itemDelegate struct{}
+// groupItem は分類ごとの見出しです。絞り込みの対象にはなりません
+type groupItem struct {
+	group     templateGroup
+	collapsed bool
+}
+
+func (g groupItem) FilterValue() string { return "" }
+
+// selection は選択されたテンプレートを選択順に保持します。
+// モデルとデリゲートの両方から参照するためポインタで共有します
+type selection struct {
+	order []string
+}
+
+// toggle adds name to the selection, or removes it if already selected
+func (s *selection) toggle(name string) {
+	for i, n := range s.order {
+		if n == name {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			return
+		}
+	}
+	s.order = append(s.order, name)
+}
+
+// has reports whether name is selected
+func (s *selection) has(name string) bool {
+	for _, n := range s.order {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+type itemDelegate struct {
+	selected *selection
+}
 
 func (d itemDelegate) Height() int                             { return 1 }
 func (d itemDelegate) Spacing() int                            { return 0 }
 func (d itemDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
 func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	if g, ok := listItem.(groupItem); ok {
+		renderGroup(w, m, index, g)
+		return
+	}
 	i, ok := listItem.(item)
 	if !ok {
 		return
 	}
 
-	str := fmt.Sprintf("%d. %s", index+1, i)
+	mark := "[ ]"
+	checked := d.selected != nil && d.selected.has(string(i))
+	if checked {
+		mark = "[x]"
+	}
+	str := fmt.Sprintf("%d. %s %s", index+1, mark, i)
 
 	fn := itemStyle.Render
+	if checked {
+		fn = checkedItemStyle.Render
+	}
 	if index == m.Index() {
 		fn = func(s ...string) string {
 			return selectedItemStyle.Render("> " + strings.Join(s, " "))
@@ -56,6 +110,29 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 	fmt.Fprint(w, fn(str))
 }
 
+// renderGroup renders a collapsible group heading
+func renderGroup(w io.Writer, m list.Model, index int, g groupItem) {
+	arrow := "▾"
+	if g.collapsed {
+		arrow = "▸"
+	}
+	str := fmt.Sprintf("%s %s (%d)", arrow, g.group.Label(), len(g.group.Names))
+
+	fn := groupItemStyle.Render
+	if index == m.Index() {
+		fn = func(s ...string) string {
+			return selectedItemStyle.Render("> " + strings.Join(s, " "))
+		}
+	}
+	fmt.Fprint(w, fn(str))
+}
+
+// isTerminal reports whether f is a character device such as a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
 // findTemplates returns a list of template names found in the cache directory
 func findTemplates(cacheDir string) ([]string, error) {
 	var templates []string
@@ -78,58 +155,320 @@ func findTemplates(cacheDir string) ([]string, error) {
 	return templates, err
 }
 
-// runInteractiveSelector runs the interactive template selector
-func runInteractiveSelector(cacheDir string) (string, error) {
+// runInteractiveSelector runs the interactive template selector, grouped by category.
+// category が空でなければその分類のテンプレートだけを表示します。
+// confirm が nil でなければ、確定前に生成結果の確認画面を表示します
+func runInteractiveSelector(sources Sources, category string, confirm confirmFunc) ([]string, error) {
 	// キャッシュディレクトリが存在しない場合は、自動的に取得
-	if _, err := os.Stat(cacheDir); os.IsNotExist(err) {
-		fmt.Println("Cache not found. Cloning github/gitignore repository...")
-		if err := cloneCache(cacheDir); err != nil {
-			fmt.Fprintf(os.Stderr, "Error cloning cache: %v\n", err)
-			os.Exit(1)
-		}
+	if err := sources.EnsureCloned(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error cloning cache: %v\n", err)
+		os.Exit(1)
 	}
-	// キャッシュディレクトリ内のすべての .gitignore ファイルを再帰的に取得
-	templateNames, err := findTemplates(cacheDir)
+	// すべてのソースの .gitignore ファイルを再帰的に取得
+	templateNames, err := sources.Templates()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	items := make([]list.Item, len(templateNames))
-	for i, name := range templateNames {
-		items[i] = item(name)
+	if category != "" {
+		if templateNames, err = filterCategory(templateNames, category); err != nil {
+			return nil, err
+		}
 	}
+	return runSelector("Select gitignore templates", templateNames, selectorOptions{
+		preview: sourcesPreview(sources),
+		confirm: confirm,
+		grouped: true,
+	})
+}
 
-	// リストを作成
-	l := list.New(items, itemDelegate{}, 10, 0)
-	l.Title = "Select a gitignore template"
-	l.SetFilteringEnabled(true)
-	l.Styles.Title = titleStyle
-	l.Styles.PaginationStyle = paginationStyle
-	l.Styles.HelpStyle = helpStyle
+// toggleKey はハイライト中の項目の選択を切り替えるキーです
+var toggleKey = key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "toggle"))
 
-	// モデルを作成
-	m := templateModel{list: l}
+// プレビューをスクロールするキー
+var (
+	previewDownKey = key.NewBinding(key.WithKeys("ctrl+d", "shift+down"), key.WithHelp("ctrl+d", "scroll preview down"))
+	previewUpKey   = key.NewBinding(key.WithKeys("ctrl+u", "shift+up"), key.WithHelp("ctrl+u", "scroll preview up"))
+)
+
+var (
+	previewStyle      = lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).BorderLeft(true).PaddingLeft(1)
+	previewTitleStyle = lipgloss.NewStyle().Bold(true)
+	previewStatsStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+// templatePreview はプレビュー欄に表示するテンプレートの内容と取得元です
+type templatePreview struct {
+	content string
+	source  string
+}
+
+// previewFunc loads the preview for the named item
+type previewFunc func(name string) (templatePreview, error)
+
+// sourcesPreview returns a previewFunc that reads templates from the sources
+func sourcesPreview(sources Sources) previewFunc {
+	return func(name string) (templatePreview, error) {
+		src, path, err := sources.Lookup(name)
+		if err != nil {
+			return templatePreview{}, err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return templatePreview{}, err
+		}
+		return templatePreview{content: string(content), source: src.Name}, nil
+	}
+}
 
-	// プログラムを実行
-	p := tea.NewProgram(m, tea.WithAltScreen())
+// previewStats summarises the rules in a preview
+func previewStats(p templatePreview) string {
+	rules := NewMatcher([]byte(p.content)).Rules
+	negations := 0
+	for _, rule := range rules {
+		if rule.Negate {
+			negations++
+		}
+	}
+	return fmt.Sprintf("%d rules · %d negations · source: %s", len(rules), negations, p.source)
+}
+
+// 確認画面のキー
+var (
+	confirmAcceptKey = key.NewBinding(key.WithKeys("enter", "y"), key.WithHelp("enter/y", "accept"))
+	confirmBackKey   = key.NewBinding(key.WithKeys("b", "backspace"), key.WithHelp("b", "back"))
+	confirmCancelKey = key.NewBinding(key.WithKeys("esc", "q", "ctrl+c"), key.WithHelp("esc/q", "cancel"))
+	confirmScrollKey = key.NewBinding(key.WithKeys("up", "down"), key.WithHelp("↑/↓", "scroll"))
+)
+
+// confirmFunc renders the result of writing the selected names, shown before they are accepted
+type confirmFunc func(names []string) (string, error)
+
+// colorDiff colors a unified diff for the confirmation screen
+func colorDiff(diff []byte) string {
+	if len(diff) == 0 {
+		return "No changes"
+	}
+
+	added := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	removed := lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	hunk := lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+
+	lines := strings.Split(strings.TrimSuffix(string(diff), "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			lines[i] = previewTitleStyle.Render(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = added.Render(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = removed.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = hunk.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// selectorOptions は選択画面の追加機能です
+type selectorOptions struct {
+	// preview が nil でなければ、ハイライト中の項目の内容を右側に表示します
+	preview previewFunc
+	// confirm が nil でなければ、確定前にその結果を表示して確認を求めます
+	confirm confirmFunc
+	// grouped が true なら分類ごとに折りたためる見出しを付けます
+	grouped bool
+	// filter が nil でなければ、名前のあいまい検索の代わりに使います
+	filter list.FilterFunc
+	// filterText が空でなければ、その内容で絞り込んだ状態で開きます
+	filterText string
+}
+
+// runSelector lets the user pick any number of names with a fuzzy-searchable list.
+// 選択した順に返し、何も選択せずに確定した場合はハイライト中の項目を返します
+func runSelector(title string, names []string, opts selectorOptions) ([]string, error) {
+	m := newTemplateModel(title, names, opts)
+
+	// プログラムを実行（標準出力が端末でなければ、結果を出力できるよう画面は標準エラーに描く）
+	programOpts := []tea.ProgramOption{tea.WithAltScreen()}
+	if !isTerminal(os.Stdout) {
+		programOpts = append(programOpts, tea.WithOutput(os.Stderr))
+	}
+	p := tea.NewProgram(m, programOpts...)
 
 	// 結果を取得
 	result, err := p.Run()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if m, ok := result.(templateModel); ok {
-		return m.choice, nil
+		return m.choices, nil
 	}
 
-	return "", nil
+	return nil, nil
+}
+
+// newTemplateModel builds the selector model for names
+func newTemplateModel(title string, names []string, opts selectorOptions) templateModel {
+	preview := opts.preview
+	selected := &selection{}
+
+	// リストを作成
+	l := list.New(nil, itemDelegate{selected: selected}, 10, 0)
+	l.SetFilteringEnabled(true)
+	l.Styles.Title = titleStyle
+	l.Styles.PaginationStyle = paginationStyle
+	l.Styles.HelpStyle = helpStyle
+	l.AdditionalShortHelpKeys = func() []key.Binding {
+		if preview == nil {
+			return []key.Binding{toggleKey}
+		}
+		return []key.Binding{toggleKey, previewDownKey, previewUpKey}
+	}
+	l.AdditionalFullHelpKeys = l.AdditionalShortHelpKeys
+
+	// モデルを作成
+	m := templateModel{
+		list:      l,
+		title:     title,
+		selected:  selected,
+		preview:   preview,
+		previews:  make(map[string]templatePreview),
+		viewport:  viewport.New(0, 0),
+		confirm:   opts.confirm,
+		result:    viewport.New(0, 0),
+		names:     names,
+		collapsed: make(map[string]bool),
+	}
+	if opts.grouped {
+		m.groups = groupTemplates(names)
+	}
+	if opts.filter != nil {
+		m.list.Filter = opts.filter
+	}
+	m.rebuildItems()
+	if opts.filterText != "" {
+		m.list.SetFilterText(opts.filterText)
+	}
+	m.updateTitle()
+	m.syncPreview()
+	return m
 }
 
 // テンプレート選択用のインタラクティブUI
 type templateModel struct {
 	list     list.Model
-	choice   string
+	title    string
+	selected *selection
+	choices  []string
 	quitting bool
+
+	// 分類ごとの見出し（groups が nil なら見出しを付けない）
+	names     []string
+	groups    []templateGroup
+	collapsed map[string]bool
+
+	// プレビュー欄
+	preview     previewFunc
+	previews    map[string]templatePreview
+	previewName string
+	viewport    viewport.Model
+
+	// 確認画面
+	confirm    confirmFunc
+	confirming bool
+	confirmErr error
+	pending    []string
+	result     viewport.Model
+}
+
+// updateTitle shows the number of selected templates in the list title
+func (m *templateModel) updateTitle() {
+	m.list.Title = fmt.Sprintf("%s (%d selected)", m.title, len(m.selected.order))
+}
+
+// rebuildItems sets the list items, leaving out the templates of collapsed groups
+func (m *templateModel) rebuildItems() tea.Cmd {
+	var items []list.Item
+	if m.groups == nil {
+		for _, name := range m.names {
+			items = append(items, item(name))
+		}
+		return m.list.SetItems(items)
+	}
+
+	for _, g := range m.groups {
+		collapsed := m.collapsed[g.Category]
+		items = append(items, groupItem{group: g, collapsed: collapsed})
+		if collapsed {
+			continue
+		}
+		for _, name := range g.Names {
+			items = append(items, item(name))
+		}
+	}
+	return m.list.SetItems(items)
+}
+
+// expandAll opens every collapsed group
+func (m *templateModel) expandAll() tea.Cmd {
+	if len(m.collapsed) == 0 {
+		return nil
+	}
+	m.collapsed = make(map[string]bool)
+	return m.rebuildItems()
+}
+
+// highlighted returns the name of the highlighted template, or "" if the list
+// is empty or a group heading is highlighted
+func (m templateModel) highlighted() string {
+	if len(m.list.VisibleItems()) == 0 {
+		return ""
+	}
+	i, ok := m.list.SelectedItem().(item)
+	if !ok {
+		return ""
+	}
+	return string(i)
+}
+
+// toggleGroup collapses or expands the highlighted group heading, reporting
+// false if no heading is highlighted
+func (m *templateModel) toggleGroup() (tea.Cmd, bool) {
+	if len(m.list.VisibleItems()) == 0 {
+		return nil, false
+	}
+	g, ok := m.list.SelectedItem().(groupItem)
+	if !ok {
+		return nil, false
+	}
+	m.collapsed[g.group.Category] = !g.collapsed
+	return m.rebuildItems(), true
+}
+
+// syncPreview loads the preview for the highlighted item when it changes
+func (m *templateModel) syncPreview() {
+	name := m.highlighted()
+	if m.preview == nil || name == m.previewName {
+		return
+	}
+	m.previewName = name
+	if name == "" {
+		m.viewport.SetContent("")
+		return
+	}
+
+	// 一度読み込んだ内容は使い回す
+	p, ok := m.previews[name]
+	if !ok {
+		var err error
+		if p, err = m.preview(name); err != nil {
+			p = templatePreview{content: fmt.Sprintf("Error: %v", err)}
+		}
+		m.previews[name] = p
+	}
+	m.viewport.SetContent(p.content)
+	m.viewport.GotoTop()
 }
 
 func (m templateModel) Init() tea.Cmd {
@@ -140,23 +479,115 @@ func (m templateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		h, v := docStyle.GetFrameSize()
-		m.list.SetSize(msg.Width-h, msg.Height-v)
+		width, height := msg.Width-h, msg.Height-v
+		// 確認画面は見出し・空行・ヘルプとその余白の4行を除いた高さを使う
+		m.result.Width = width - titleStyle.GetHorizontalFrameSize()
+		m.result.Height = height - 4
+		if m.preview != nil {
+			// 左にリスト、右にプレビュー（見出しと統計の2行を除く）を並べる
+			listWidth := width / 2
+			m.list.SetSize(listWidth, height)
+			m.viewport.Width = width - listWidth - previewStyle.GetHorizontalFrameSize()
+			m.viewport.Height = height - 3
+			return m, nil
+		}
+		m.list.SetSize(width, height)
 		return m, nil
 	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyCtrlC, tea.KeyEsc:
+		if m.confirming {
+			return m.updateConfirm(msg)
+		}
+		switch {
+		case msg.Type == tea.KeyCtrlC, msg.Type == tea.KeyEsc:
 			m.quitting = true
 			return m, tea.Quit
-		case tea.KeyEnter:
-			if len(m.list.VisibleItems()) > 0 {
-				m.choice = m.list.SelectedItem().FilterValue()
+		case msg.Type == tea.KeyEnter && m.list.FilterState() != list.Filtering:
+			// 絞り込みの入力中の enter はリストに渡して絞り込みを確定させ、続けて選択できるようにする。
+			// 見出しの上では確定せずに折りたたみを切り替える
+			if cmd, ok := m.toggleGroup(); ok {
+				return m, cmd
+			}
+			var choices []string
+			if len(m.selected.order) > 0 {
+				choices = append([]string(nil), m.selected.order...)
+			} else if name := m.highlighted(); name != "" {
+				choices = []string{name}
+			}
+			if choices == nil {
+				return m, nil
+			}
+			if m.confirm == nil {
+				m.choices = choices
 				return m, tea.Quit
 			}
+			m.showConfirm(choices)
+			return m, nil
+		case key.Matches(msg, toggleKey) && m.list.FilterState() != list.Filtering:
+			// 絞り込みの入力中はスペースをそのまま入力欄に渡す
+			if cmd, ok := m.toggleGroup(); ok {
+				return m, cmd
+			}
+			if name := m.highlighted(); name != "" {
+				m.selected.toggle(name)
+				m.updateTitle()
+			}
+			return m, nil
+		case key.Matches(msg, m.list.KeyMap.Filter) && m.list.FilterState() == list.Unfiltered:
+			// 折りたたまれたテンプレートも絞り込めるよう、すべて開いてから絞り込みを始める
+			expand := m.expandAll()
+			var cmd tea.Cmd
+			m.list, cmd = m.list.Update(msg)
+			m.syncPreview()
+			return m, tea.Batch(expand, cmd)
+		case m.preview != nil && key.Matches(msg, previewDownKey):
+			m.viewport.HalfPageDown()
+			return m, nil
+		case m.preview != nil && key.Matches(msg, previewUpKey):
+			m.viewport.HalfPageUp()
+			return m, nil
 		}
 	}
 
 	var cmd tea.Cmd
 	m.list, cmd = m.list.Update(msg)
+	m.syncPreview()
+	return m, cmd
+}
+
+// showConfirm switches to the confirmation screen for choices
+func (m *templateModel) showConfirm(choices []string) {
+	m.pending = choices
+	content, err := m.confirm(choices)
+	m.confirmErr = err
+	if err != nil {
+		content = fmt.Sprintf("Error: %v", err)
+	}
+	m.result.SetContent(content)
+	m.result.GotoTop()
+	m.confirming = true
+}
+
+// updateConfirm handles keys on the confirmation screen
+func (m templateModel) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, confirmAcceptKey):
+		// 生成に失敗した場合は確定できない
+		if m.confirmErr != nil {
+			return m, nil
+		}
+		m.choices = m.pending
+		return m, tea.Quit
+	case key.Matches(msg, confirmBackKey):
+		// 選択を保ったまま一覧に戻る
+		m.confirming = false
+		return m, nil
+	case key.Matches(msg, confirmCancelKey):
+		m.quitting = true
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd
+	m.result, cmd = m.result.Update(msg)
 	return m, cmd
 }
 
@@ -164,5 +595,32 @@ func (m templateModel) View() string {
 	if m.quitting {
 		return ""
 	}
-	return "\n" + m.list.View()
+	if m.confirming {
+		return "\n" + m.confirmView()
+	}
+	if m.preview == nil {
+		return "\n" + m.list.View()
+	}
+	return "\n" + lipgloss.JoinHorizontal(lipgloss.Top, m.list.View(), m.previewView())
+}
+
+// previewView renders the preview pane for the highlighted item
+func (m templateModel) previewView() string {
+	if m.previewName == "" {
+		return ""
+	}
+	p := m.previews[m.previewName]
+	header := previewTitleStyle.Render(m.previewName) + "\n" + previewStatsStyle.Render(previewStats(p)) + "\n"
+	return previewStyle.Render(header + "\n" + m.viewport.View())
+}
+
+// confirmView renders the confirmation screen
+func (m templateModel) confirmView() string {
+	title := titleStyle.Render(previewTitleStyle.Render("Confirm: " + strings.Join(m.pending, ", ")))
+	bindings := []key.Binding{confirmAcceptKey, confirmBackKey, confirmCancelKey, confirmScrollKey}
+	if m.confirmErr != nil {
+		bindings = bindings[1:]
+	}
+	footer := helpStyle.Render(help.New().ShortHelpView(bindings))
+	return title + "\n\n" + titleStyle.Render(m.result.View()) + "\n" + footer
 }
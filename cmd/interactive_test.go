@@ -1,10 +1,17 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"slices"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 func TestFindTemplates(t *testing.T) {
@@ -49,3 +56,277 @@ func TestFindTemplates(t *testing.T) {
 		}
 	}
 }
+
+// newTestTemplateModel builds a selector model without starting a program
+func newTestTemplateModel(names ...string) templateModel {
+	m := newTemplateModel("Select", names, selectorOptions{})
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	return next.(templateModel)
+}
+
+// press sends a sequence of keys to the model
+func press(m templateModel, keys ...tea.KeyMsg) templateModel {
+	for _, k := range keys {
+		next, _ := m.Update(k)
+		m = next.(templateModel)
+	}
+	return m
+}
+
+var (
+	keySpace = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	keyDown  = tea.KeyMsg{Type: tea.KeyDown}
+	keyUp    = tea.KeyMsg{Type: tea.KeyUp}
+	keyEnter = tea.KeyMsg{Type: tea.KeyEnter}
+)
+
+func TestTemplateModelMultiSelect(t *testing.T) {
+	m := newTestTemplateModel("Go", "Node", "Python")
+
+	// Python, Go の順に選択し、Node は選択してから解除
+	m = press(m, keyDown, keySpace, keyDown, keySpace, keyUp, keySpace, keyUp, keySpace)
+	if m.list.Title != "Select (2 selected)" {
+		t.Errorf("title = %q, expected %q", m.list.Title, "Select (2 selected)")
+	}
+
+	m = press(m, keyEnter)
+	expected := []string{"Python", "Go"}
+	if !reflect.DeepEqual(m.choices, expected) {
+		t.Errorf("choices = %v, expected %v", m.choices, expected)
+	}
+}
+
+// typeKeys sends each rune as a key press and runs the resulting commands,
+// so that the list receives its asynchronous filter results
+func typeKeys(m templateModel, keys ...tea.KeyMsg) templateModel {
+	for _, k := range keys {
+		next, cmd := m.Update(k)
+		m = runCmds(next.(templateModel), cmd)
+	}
+	return m
+}
+
+// runCmds feeds the messages of cmd back into the model.
+// カーソルの点滅のように待ち続けるコマンドは打ち切ります
+func runCmds(m templateModel, cmd tea.Cmd) templateModel {
+	if cmd == nil {
+		return m
+	}
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+	var msg tea.Msg
+	select {
+	case msg = <-done:
+	case <-time.After(50 * time.Millisecond):
+		return m
+	}
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		for _, c := range batch {
+			m = runCmds(m, c)
+		}
+		return m
+	}
+	if msg == nil {
+		return m
+	}
+	next, nextCmd := m.Update(msg)
+	return runCmds(next.(templateModel), nextCmd)
+}
+
+// runes returns a key press for each character of s
+func runes(s string) []tea.KeyMsg {
+	var keys []tea.KeyMsg
+	for _, r := range s {
+		keys = append(keys, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return keys
+}
+
+func TestTemplateModelEnterAppliesFilter(t *testing.T) {
+	m := newTestTemplateModel("Go", "Rust", "Python")
+
+	// 絞り込みの入力中の enter は確定せず、絞り込みを適用する
+	m = typeKeys(m, runes("/Rus")...)
+	m = typeKeys(m, keyEnter)
+	if m.choices != nil || m.quitting {
+		t.Fatalf("enter while filtering should not submit, got choices %v", m.choices)
+	}
+	if m.list.FilterState() != list.FilterApplied {
+		t.Fatalf("filter state = %v, expected applied", m.list.FilterState())
+	}
+
+	// 絞り込みを適用した後の enter で確定する
+	m = typeKeys(m, keySpace, keyEnter)
+	expected := []string{"Rust"}
+	if !reflect.DeepEqual(m.choices, expected) {
+		t.Errorf("choices = %v, expected %v", m.choices, expected)
+	}
+}
+
+func TestTemplateModelEnterWithoutSelection(t *testing.T) {
+	m := newTestTemplateModel("Go", "Node")
+	m = press(m, keyDown, keyEnter)
+	if !reflect.DeepEqual(m.choices, []string{"Node"}) {
+		t.Errorf("choices = %v, expected [Node]", m.choices)
+	}
+}
+
+func TestTemplateModelPreview(t *testing.T) {
+	contents := map[string]string{
+		"Go":   "# Go\n*.exe\n!keep.exe\nvendor/\n",
+		"Node": "node_modules/\n",
+	}
+	loads := 0
+	preview := func(name string) (templatePreview, error) {
+		loads++
+		return templatePreview{content: contents[name], source: "github"}, nil
+	}
+
+	m := newTemplateModel("Select", []string{"Go", "Node"}, selectorOptions{preview: preview})
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m = next.(templateModel)
+
+	if m.previewName != "Go" {
+		t.Fatalf("previewName = %q, expected Go", m.previewName)
+	}
+	if got := previewStats(m.previews["Go"]); got != "3 rules · 1 negations · source: github" {
+		t.Errorf("previewStats() = %q", got)
+	}
+	if !strings.Contains(m.View(), "vendor/") {
+		t.Errorf("View() does not show the preview of Go:\n%s", m.View())
+	}
+
+	// ハイライトを移動すると内容が切り替わり、戻ると読み込み済みの内容を使う
+	m = press(m, keyDown)
+	if m.previewName != "Node" || !strings.Contains(m.View(), "node_modules/") {
+		t.Errorf("preview did not follow the highlight: %q", m.previewName)
+	}
+	m = press(m, keyUp)
+	if m.previewName != "Go" || loads != 2 {
+		t.Errorf("previewName = %q, loads = %d, expected Go and 2", m.previewName, loads)
+	}
+}
+
+func TestTemplateModelPreviewScroll(t *testing.T) {
+	var lines []string
+	for i := 0; i < 100; i++ {
+		lines = append(lines, fmt.Sprintf("pattern%d", i))
+	}
+	preview := func(name string) (templatePreview, error) {
+		return templatePreview{content: strings.Join(lines, "\n"), source: "github"}, nil
+	}
+
+	m := newTemplateModel("Select", []string{"Long"}, selectorOptions{preview: preview})
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m = next.(templateModel)
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyCtrlD})
+	if m.viewport.YOffset == 0 {
+		t.Errorf("ctrl+d did not scroll the preview")
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyCtrlU})
+	if m.viewport.YOffset != 0 {
+		t.Errorf("ctrl+u did not scroll the preview back, offset %d", m.viewport.YOffset)
+	}
+}
+
+func TestTemplateModelConfirm(t *testing.T) {
+	var rendered [][]string
+	confirm := func(names []string) (string, error) {
+		rendered = append(rendered, names)
+		if len(names) == 1 && names[0] == "Broken" {
+			return "", fmt.Errorf("template Broken not found")
+		}
+		return "# >>> mushi: " + strings.Join(names, ",") + "\n", nil
+	}
+	newModel := func() templateModel {
+		m := newTemplateModel("Select", []string{"Go", "Node", "Broken"}, selectorOptions{confirm: confirm})
+		next, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+		return next.(templateModel)
+	}
+	keyRune := func(r rune) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}} }
+
+	// enter で確認画面に移り、結果を表示する
+	m := press(newModel(), keySpace, keyDown, keySpace, keyEnter)
+	if !m.confirming || m.choices != nil {
+		t.Fatalf("enter did not open the confirmation screen")
+	}
+	if !strings.Contains(m.View(), "# >>> mushi: Go,Node") {
+		t.Errorf("confirmation screen does not show the result:\n%s", m.View())
+	}
+
+	// b で選択を保ったまま戻り、選び直してから確定する
+	m = press(m, keyRune('b'))
+	if m.confirming || m.list.Title != "Select (2 selected)" {
+		t.Fatalf("back did not return to the list with the selection kept")
+	}
+	m = press(m, keySpace, keyEnter, keyRune('y'))
+	if !reflect.DeepEqual(m.choices, []string{"Go"}) {
+		t.Errorf("choices = %v, expected [Go]", m.choices)
+	}
+	if len(rendered) != 2 {
+		t.Errorf("confirm called %d times, expected 2", len(rendered))
+	}
+
+	// esc で何も選ばずに終了する
+	m = press(newModel(), keyEnter, tea.KeyMsg{Type: tea.KeyEsc})
+	if !m.quitting || m.choices != nil {
+		t.Errorf("cancel returned %v", m.choices)
+	}
+
+	// 生成に失敗した場合は確定できない
+	m = press(newModel(), keyDown, keyDown, keyEnter, keyEnter)
+	if m.choices != nil || !strings.Contains(m.View(), "template Broken not found") {
+		t.Errorf("accepted a selection that failed to render: %v", m.choices)
+	}
+}
+
+func TestTemplateModelGroups(t *testing.T) {
+	m := newTemplateModel("Select", []string{"Go", "Global/Vim", "community/Python/Nikola", "Node"}, selectorOptions{grouped: true})
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m = next.(templateModel)
+
+	labels := func(m templateModel) []string {
+		var values []string
+		for _, it := range m.list.Items() {
+			switch it := it.(type) {
+			case groupItem:
+				values = append(values, "# "+it.group.Label())
+			case item:
+				values = append(values, string(it))
+			}
+		}
+		return values
+	}
+
+	expected := []string{"# Root", "Go", "Node", "# Global/", "Global/Vim", "# community/", "community/Python/Nikola"}
+	if got := labels(m); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("items = %v, expected %v", got, expected)
+	}
+
+	// 見出しの上の enter は確定せずに折りたたむ
+	m = press(m, keyEnter)
+	if m.choices != nil {
+		t.Fatalf("enter on a heading returned %v", m.choices)
+	}
+	expected = []string{"# Root", "# Global/", "Global/Vim", "# community/", "community/Python/Nikola"}
+	if got := labels(m); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("items after collapsing = %v, expected %v", got, expected)
+	}
+
+	// 見出しの上の space も折りたたみを切り替え、選択には加えない
+	m = press(m, keyDown, keySpace)
+	if len(m.selected.order) != 0 {
+		t.Errorf("space on a heading selected %v", m.selected.order)
+	}
+	expected = []string{"# Root", "# Global/", "# community/", "community/Python/Nikola"}
+	if got := labels(m); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("items after collapsing twice = %v, expected %v", got, expected)
+	}
+
+	// 絞り込みを始めるとすべて開く
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	if len(m.list.Items()) != 7 {
+		t.Errorf("filtering did not expand the groups: %v", labels(m))
+	}
+}
@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// lint で報告する問題の種類
+const (
+	lintDuplicate           = "duplicate"
+	lintUnreachable         = "unreachable"
+	lintIneffectiveNegation = "ineffective-negation"
+	lintTrailingWhitespace  = "trailing-whitespace"
+	lintInvalidDoubleStar   = "invalid-double-star"
+)
+
+// LintIssue is a problem found in an ignore file
+type LintIssue struct {
+	Line    int    `json:"line"`
+	Kind    string `json:"kind"`
+	Pattern string `json:"pattern"`
+	Message string `json:"message"`
+	// Related は原因となった別の行の行番号です（無ければ 0）
+	Related int `json:"related,omitempty"`
+}
+
+// lintContent checks gitignore content and returns the problems in line order
+func lintContent(content []byte) []LintIssue {
+	var issues []LintIssue
+	lines := strings.Split(string(content), "\n")
+	matcher := NewMatcher(content)
+
+	// 行単位の問題（末尾の空白と不正な "**"）
+	for i, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		if _, ok := parseIgnoreRule(line); !ok {
+			continue
+		}
+		if issue, ok := lintTrailingSpace(line); ok {
+			issue.Line = i + 1
+			issues = append(issues, issue)
+		}
+		if issue, ok := lintDoubleStar(trimUnescapedSpace(line)); ok {
+			issue.Line = i + 1
+			issues = append(issues, issue)
+		}
+	}
+
+	// ルール間の問題（重複・到達不能・効果のない否定）
+	seen := make(map[string]int)
+	for i, rule := range matcher.Rules {
+		key, _, _ := normalizePattern(rule.Pattern)
+		if first, dup := seen[key]; dup && !hasOppositeBetween(matcher.Rules, first, i) {
+			issues = append(issues, LintIssue{
+				Line:    rule.Line,
+				Kind:    lintDuplicate,
+				Pattern: rule.Pattern,
+				Message: fmt.Sprintf("duplicate of line %d", matcher.Rules[first].Line),
+				Related: matcher.Rules[first].Line,
+			})
+			continue
+		}
+		seen[key] = i
+
+		if issue, ok := lintParentExcluded(matcher, i); ok {
+			issues = append(issues, issue)
+			continue
+		}
+		if issue, ok := lintShadowed(matcher.Rules, i); ok {
+			issues = append(issues, issue)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Line < issues[j].Line })
+	return issues
+}
+
+// lintTrailingSpace reports trailing whitespace that git drops or keeps unexpectedly
+func lintTrailingSpace(line string) (LintIssue, bool) {
+	trimmed := trimUnescapedSpace(line)
+	switch {
+	case trimmed != line:
+		// エスケープされていない末尾の空白は git に無視される
+		return LintIssue{
+			Kind:    lintTrailingWhitespace,
+			Pattern: trimmed,
+			Message: `trailing spaces are ignored by git; escape them with "\" if they are part of the name`,
+		}, true
+	case strings.HasSuffix(line, "\t"):
+		// タブは取り除かれず、パターンの一部として扱われる
+		return LintIssue{
+			Kind:    lintTrailingWhitespace,
+			Pattern: line,
+			Message: "trailing tab is part of the pattern",
+		}, true
+	}
+	return LintIssue{}, false
+}
+
+// lintDoubleStar reports "**" that is not a whole path segment.
+// git はこの場合 "**" を通常の "*" として扱います
+func lintDoubleStar(pattern string) (LintIssue, bool) {
+	body := strings.TrimPrefix(pattern, "!")
+	for _, segment := range strings.Split(strings.Trim(body, "/"), "/") {
+		if strings.Contains(segment, "**") && segment != "**" {
+			return LintIssue{
+				Kind:    lintInvalidDoubleStar,
+				Pattern: pattern,
+				Message: fmt.Sprintf(`"**" in %q is not a whole path segment and acts like "*"`, segment),
+			}, true
+		}
+	}
+	return LintIssue{}, false
+}
+
+// lintParentExcluded reports rules below a directory that is already excluded.
+// 除外されたディレクトリの中身は再包含できないため、否定は効果がなく、
+// 通常のルールは到達不能になります
+func lintParentExcluded(m *Matcher, index int) (LintIssue, bool) {
+	rule := m.Rules[index]
+	if !rule.Anchored || len(rule.segments) < 2 {
+		return LintIssue{}, false
+	}
+
+	// 否定はファイル全体、通常のルールはそれより前のルールだけで親を評価する
+	parents := m
+	if !rule.Negate {
+		parents = &Matcher{Rules: m.Rules[:index]}
+	}
+
+	for i := 1; i < len(rule.segments); i++ {
+		if !isLiteralSegment(rule.segments[i-1]) {
+			break
+		}
+		parent := strings.Join(rule.segments[:i], "/")
+		excluder, ignored := parents.Match(parent, true)
+		if !ignored {
+			continue
+		}
+		issue := LintIssue{
+			Line:    rule.Line,
+			Kind:    lintUnreachable,
+			Pattern: rule.Pattern,
+			Message: fmt.Sprintf("%s/ is already excluded by line %d", parent, excluder.Line),
+			Related: excluder.Line,
+		}
+		if rule.Negate {
+			issue.Kind = lintIneffectiveNegation
+			issue.Message = fmt.Sprintf("cannot re-include a path inside %s/, which is excluded by line %d", parent, excluder.Line)
+		}
+		return issue, true
+	}
+	return LintIssue{}, false
+}
+
+// lintShadowed reports literal rules whose paths an earlier rule already decides the same way
+func lintShadowed(rules []IgnoreRule, index int) (LintIssue, bool) {
+	rule := rules[index]
+	for _, segment := range rule.segments {
+		if !isLiteralSegment(segment) {
+			return LintIssue{}, false
+		}
+	}
+
+	prior := &Matcher{Rules: rules[:index]}
+	kinds := []bool{true}
+	if !rule.DirOnly {
+		kinds = append(kinds, false)
+	}
+
+	var decider *IgnoreRule
+	for _, isDir := range kinds {
+		found, _ := prior.matchPath(rule.segments, isDir)
+		if found == nil || found.Negate != rule.Negate || (decider != nil && found != decider) {
+			return LintIssue{}, false
+		}
+		decider = found
+	}
+
+	// 固定されていないルールはどの階層にも一致するため、
+	// 判定したルールも固定されておらず、間に逆のルールが無い場合に限る
+	if !rule.Anchored {
+		if decider.Anchored {
+			return LintIssue{}, false
+		}
+		for i := range rules[:index] {
+			if &rules[i] == decider && hasOppositeBetween(rules, i, index) {
+				return LintIssue{}, false
+			}
+		}
+	}
+
+	return LintIssue{
+		Line:    rule.Line,
+		Kind:    lintUnreachable,
+		Pattern: rule.Pattern,
+		Message: fmt.Sprintf("already covered by %q on line %d", decider.Pattern, decider.Line),
+		Related: decider.Line,
+	}, true
+}
+
+// hasOppositeBetween reports whether a rule of the opposite polarity to rules[to]
+// sits strictly between rules[from] and rules[to]
+func hasOppositeBetween(rules []IgnoreRule, from, to int) bool {
+	for _, r := range rules[from+1 : to] {
+		if r.Negate != rules[to].Negate {
+			return true
+		}
+	}
+	return false
+}
+
+// isLiteralSegment reports whether a pattern segment has no glob or escape characters
+func isLiteralSegment(segment string) bool {
+	return !strings.ContainsAny(segment, "*?[\\")
+}
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Report problems in a .gitignore file",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		content, err := os.ReadFile(outputPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", outputPath, err)
+			os.Exit(2)
+		}
+
+		issues := lintContent(content)
+
+		// --json が指定されたら JSON で出力
+		if lintJSON {
+			if issues == nil {
+				issues = []LintIssue{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(issues); err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+				os.Exit(2)
+			}
+		} else if len(issues) == 0 {
+			fmt.Printf("✨️ No problems found in %s\n", outputPath)
+		} else {
+			for _, issue := range issues {
+				fmt.Printf("%s:%d: [%s] %q: %s\n", outputPath, issue.Line, issue.Kind, issue.Pattern, issue.Message)
+			}
+			fmt.Printf("✗ %d problem(s) found in %s\n", len(issues), outputPath)
+		}
+
+		if len(issues) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// lintのみのオプションを記述
+var (
+	lintJSON bool
+)
+
+func init() {
+	lintCmd.Flags().BoolVar(&lintJSON, "json", false, "Print problems as JSON")
+	lintCmd.Flags().StringVarP(&outputPath, "path", "p", ".gitignore", "Path to the .gitignore file (default: .gitignore)")
+	RootCmd.AddCommand(lintCmd)
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLintContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []LintIssue
+	}{
+		{
+			name:     "clean",
+			content:  "# comment\n*.log\n!important.log\nbuild/\n",
+			expected: nil,
+		},
+		{
+			name:    "duplicate",
+			content: "node_modules\n*.log\n**/node_modules\n",
+			expected: []LintIssue{
+				{Line: 3, Kind: lintDuplicate, Pattern: "**/node_modules", Message: "duplicate of line 1", Related: 1},
+			},
+		},
+		{
+			name:     "duplicate after opposite rule is meaningful",
+			content:  "*.log\n!debug.log\n*.log\n",
+			expected: nil,
+		},
+		{
+			name:    "shadowed by broader rule",
+			content: "*.log\ndebug.log\nlogs/debug.log\n",
+			expected: []LintIssue{
+				{Line: 2, Kind: lintUnreachable, Pattern: "debug.log", Message: `already covered by "*.log" on line 1`, Related: 1},
+				{Line: 3, Kind: lintUnreachable, Pattern: "logs/debug.log", Message: `already covered by "debug.log" on line 2`, Related: 2},
+			},
+		},
+		{
+			name:     "anchored rule does not shadow unanchored rule",
+			content:  "/debug.log\ndebug.log\n",
+			expected: nil,
+		},
+		{
+			name:    "excluded parent",
+			content: "build/\n!build/keep.txt\nbuild/out\n",
+			expected: []LintIssue{
+				{Line: 2, Kind: lintIneffectiveNegation, Pattern: "!build/keep.txt", Message: "cannot re-include a path inside build/, which is excluded by line 1", Related: 1},
+				{Line: 3, Kind: lintUnreachable, Pattern: "build/out", Message: "build/ is already excluded by line 1", Related: 1},
+			},
+		},
+		{
+			name:     "contents excluded instead of directory",
+			content:  "build/*\n!build/keep.txt\n",
+			expected: nil,
+		},
+		{
+			name:    "later parent exclusion still blocks negation",
+			content: "!dist/keep\ndist\n",
+			expected: []LintIssue{
+				{Line: 1, Kind: lintIneffectiveNegation, Pattern: "!dist/keep", Message: "cannot re-include a path inside dist/, which is excluded by line 2", Related: 2},
+			},
+		},
+		{
+			name:    "trailing whitespace",
+			content: "name  \nescaped\\ \ntab\t\n",
+			expected: []LintIssue{
+				{Line: 1, Kind: lintTrailingWhitespace, Pattern: "name", Message: `trailing spaces are ignored by git; escape them with "\" if they are part of the name`},
+				{Line: 3, Kind: lintTrailingWhitespace, Pattern: "tab\t", Message: "trailing tab is part of the pattern"},
+			},
+		},
+		{
+			name:    "trailing tab is not a duplicate of the bare pattern",
+			content: "foo\t\nfoo\n",
+			expected: []LintIssue{
+				{Line: 1, Kind: lintTrailingWhitespace, Pattern: "foo\t", Message: "trailing tab is part of the pattern"},
+			},
+		},
+		{
+			name:    "invalid double star",
+			content: "**/ok\nlib/**\na/**/b\nfoo**\n!bar/**baz\n",
+			expected: []LintIssue{
+				{Line: 4, Kind: lintInvalidDoubleStar, Pattern: "foo**", Message: `"**" in "foo**" is not a whole path segment and acts like "*"`},
+				{Line: 5, Kind: lintInvalidDoubleStar, Pattern: "!bar/**baz", Message: `"**" in "**baz" is not a whole path segment and acts like "*"`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := lintContent([]byte(tt.content))
+			if !reflect.DeepEqual(issues, tt.expected) {
+				t.Errorf("lintContent() =\n%+v\nexpected\n%+v", issues, tt.expected)
+			}
+		})
+	}
+}
@@ -1,56 +1,145 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"text/template"
 
 	"github.com/spf13/cobra"
 )
 
+// TemplateInfo describes a template in the cache
+type TemplateInfo struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Source   string `json:"source"`
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	Rules    int    `json:"rules"`
+	// Commit はテンプレートを最後に変更したコミットです（ローカルのソースでは空）
+	Commit string `json:"commit,omitempty"`
+}
+
+// filterTemplates keeps the names matching pattern.
+// グロブ文字を含む場合は名前全体または最後の要素とのグロブ照合、
+// それ以外は大文字小文字を区別しない部分一致で絞り込みます
+func filterTemplates(names []string, pattern string) []string {
+	glob := strings.ContainsAny(pattern, "*?[")
+	lower := strings.ToLower(pattern)
+
+	var filtered []string
+	for _, name := range names {
+		_, template := splitSourcePrefix(name)
+		var ok bool
+		if glob {
+			full, _ := path.Match(pattern, template)
+			base, _ := path.Match(pattern, path.Base(template))
+			ok = full || base
+		} else {
+			ok = strings.Contains(strings.ToLower(template), lower)
+		}
+		if ok {
+			filtered = append(filtered, name)
+		}
+	}
+	return filtered
+}
+
+// templateInfos collects the metadata of the named templates
+func templateInfos(sources Sources, names []string) ([]TemplateInfo, error) {
+	// ソースごとに git の履歴を一度だけ読む
+	commits := make(map[string]map[string]string)
+
+	infos := make([]TemplateInfo, 0, len(names))
+	for _, name := range names {
+		src, templatePath, err := sources.Lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		content, err := os.ReadFile(templatePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %w", name, err)
+		}
+
+		info := TemplateInfo{
+			Name:     name,
+			Category: templateCategory(name),
+			Source:   src.Name,
+			Path:     templatePath,
+			Size:     int64(len(content)),
+			Rules:    len(NewMatcher(content).Rules),
+		}
+
+		if !src.Local {
+			byPath, ok := commits[src.Dir]
+			if !ok {
+				if byPath, err = lastModifiedCommits(src.Dir); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to read the history of %s: %v\n", src.Name, err)
+				}
+				commits[src.Dir] = byPath
+			}
+			if rel, err := filepath.Rel(src.Dir, templatePath); err == nil {
+				info.Commit = byPath[filepath.ToSlash(rel)]
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List available gitignore templates",
 	Run: func(cmd *cobra.Command, args []string) {
-		// キャッシュディレクトリのパスを取得
-		cacheDir, err := getCacheDir()
+		// テンプレートの取得元を解決
+		sources, err := getSources()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error getting cache directory: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error resolving template sources: %v\n", err)
 			os.Exit(1)
 		}
 
 		// キャッシュディレクトリが存在しない場合はクローン
-		if _, err := os.Stat(cacheDir); os.IsNotExist(err) {
-			fmt.Println("Cache not found. Cloning github/gitignore repository...")
-			if err := cloneCache(cacheDir); err != nil {
-				fmt.Fprintf(os.Stderr, "failed to clone cache: %v", err)
+		if err := sources.EnsureCloned(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to clone cache: %v", err)
+			os.Exit(1)
+		}
+
+		// すべてのソースの .gitignore ファイルを再帰的に検索
+		templates, err := sources.Templates()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading cache directory: %v\n", err)
+			os.Exit(1)
+		}
+
+		// --category が指定されたらその分類に絞り込む
+		if category != "" {
+			if templates, err = filterCategory(templates, category); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
 		}
 
-		// キャッシュディレクトリ内のすべての .gitignore ファイルを再帰的に検索
-		var templates []string
-		err = filepath.Walk(cacheDir, func(path string, info os.FileInfo, err error) error {
+		// --filter が指定されたら名前で絞り込む
+		if listFilter != "" {
+			templates = filterTemplates(templates, listFilter)
+		}
+
+		// --json / --format が指定されたらメタデータを出力
+		if listJSON || listFormat != "" {
+			infos, err := templateInfos(sources, templates)
 			if err != nil {
-				return err
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
 			}
-			if !info.IsDir() && strings.HasSuffix(info.Name(), ".gitignore") {
-				// キャッシュディレクトリからの相対パスを取得
-				relPath, err := filepath.Rel(cacheDir, path)
-				if err != nil {
-					return err
-				}
-				// .gitignore 拡張子を除去
-				templateName := strings.TrimSuffix(relPath, ".gitignore")
-				templates = append(templates, templateName)
+			if err := printTemplateInfos(infos); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
 			}
-			return nil
-		})
-
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading cache directory: %v\n", err)
-			os.Exit(1)
+			return
 		}
 
 		// テンプレートをソートして表示
@@ -59,6 +148,12 @@ var listCmd = &cobra.Command{
 			return
 		}
 
+		// --tree が指定されたら分類ごとの木として表示
+		if listTree {
+			printTemplateTree(os.Stdout, templates)
+			return
+		}
+
 		fmt.Printf("Available gitignore templates (%d):\n", len(templates))
 		for _, template := range templates {
 			fmt.Printf("  %s\n", template)
@@ -66,6 +161,43 @@ var listCmd = &cobra.Command{
 	},
 }
 
+// printTemplateInfos writes infos as JSON, or one line per template with --format
+func printTemplateInfos(infos []TemplateInfo) error {
+	if listJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(infos); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return nil
+	}
+
+	tmpl, err := template.New("format").Parse(listFormat)
+	if err != nil {
+		return fmt.Errorf("invalid --format: %w", err)
+	}
+	for _, info := range infos {
+		if err := tmpl.Execute(os.Stdout, info); err != nil {
+			return fmt.Errorf("invalid --format: %w", err)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// listのみのオプションを記述
+var (
+	listTree   bool
+	listJSON   bool
+	listFormat string
+	listFilter string
+)
+
 func init() {
+	listCmd.Flags().BoolVar(&listTree, "tree", false, "Show templates as a tree grouped by category")
+	listCmd.Flags().StringVar(&category, "category", "", "Only list templates in this category (root, global, community)")
+	listCmd.Flags().BoolVar(&listJSON, "json", false, "Print template metadata as JSON")
+	listCmd.Flags().StringVar(&listFormat, "format", "", "Print each template with a Go template, e.g. '{{.Name}}\\t{{.Rules}}'")
+	listCmd.Flags().StringVar(&listFilter, "filter", "", "Only list templates matching a glob or containing a substring")
 	RootCmd.AddCommand(listCmd)
 }
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFilterTemplates(t *testing.T) {
+	names := []string{"Go", "Global/GoLand", "Node", "community/Golang/Hugo", "github:Python"}
+
+	tests := []struct {
+		pattern  string
+		expected []string
+	}{
+		{pattern: "go", expected: []string{"Go", "Global/GoLand", "community/Golang/Hugo"}},
+		{pattern: "Go*", expected: []string{"Go", "Global/GoLand"}},
+		{pattern: "Global/*", expected: []string{"Global/GoLand"}},
+		{pattern: "Hugo", expected: []string{"community/Golang/Hugo"}},
+		{pattern: "Py*", expected: []string{"github:Python"}},
+		{pattern: "rust", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if got := filterTemplates(names, tt.pattern); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("filterTemplates(%q) = %v, expected %v", tt.pattern, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTemplateInfos(t *testing.T) {
+	repo := newTestRepo(t)
+	repo.write("Go.gitignore", "*.exe\n")
+	repo.write("Global/Vim.gitignore", "*.swp\n")
+	first := repo.commit("first")
+	repo.write("Go.gitignore", "# Go\n*.exe\n!keep.exe\nvendor/\n")
+	second := repo.commit("second")
+	repoDir := repo.dir
+
+	localDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(localDir, "Zig.gitignore"), []byte("zig-out/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sources := Sources{
+		{Name: "github", Dir: repoDir},
+		{Name: "local", Dir: localDir, Local: true},
+	}
+	infos, err := templateInfos(sources, []string{"github:Go", "github:Global/Vim", "local:Zig"})
+	if err != nil {
+		t.Fatalf("templateInfos failed: %v", err)
+	}
+
+	expected := []TemplateInfo{
+		{Name: "github:Go", Category: categoryRoot, Source: "github", Path: filepath.Join(repoDir, "Go.gitignore"), Size: 29, Rules: 3, Commit: second},
+		{Name: "github:Global/Vim", Category: categoryGlobal, Source: "github", Path: filepath.Join(repoDir, "Global", "Vim.gitignore"), Size: 6, Rules: 1, Commit: first},
+		{Name: "local:Zig", Category: categoryRoot, Source: "local", Path: filepath.Join(localDir, "Zig.gitignore"), Size: 9, Rules: 1},
+	}
+	if !reflect.DeepEqual(infos, expected) {
+		t.Errorf("templateInfos() =\n%+v\nexpected\n%+v", infos, expected)
+	}
+}
+
+func TestLastModifiedCommitsShallow(t *testing.T) {
+	repo := newTestRepo(t)
+	repo.write("Go.gitignore", "*.exe\n")
+	first := repo.commit("Go")
+	repo.write("Rust.gitignore", "target/\n")
+	second := repo.commit("Rust")
+	repo.write("Node.gitignore", "node_modules/\n")
+	third := repo.commit("Node")
+
+	clone := func(t *testing.T) string {
+		t.Helper()
+		cloneDir := filepath.Join(t.TempDir(), "clone")
+		if output, err := exec.Command("git", "clone", "-q", "--depth", "1", "file://"+repo.dir, cloneDir).CombinedOutput(); err != nil {
+			t.Fatalf("git clone failed: %v\n%s", err, output)
+		}
+		return cloneDir
+	}
+
+	t.Run("full history", func(t *testing.T) {
+		commits, err := lastModifiedCommits(repo.dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]string{"Go.gitignore": first, "Rust.gitignore": second, "Node.gitignore": third}
+		if !reflect.DeepEqual(commits, expected) {
+			t.Errorf("expected %v, got %v", expected, commits)
+		}
+	})
+
+	// 浅いクローンは履歴を取得してから各ファイルのコミットを求める
+	t.Run("shallow clone", func(t *testing.T) {
+		cloneDir := clone(t)
+		commits, err := lastModifiedCommits(cloneDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]string{"Go.gitignore": first, "Rust.gitignore": second, "Node.gitignore": third}
+		if !reflect.DeepEqual(commits, expected) {
+			t.Errorf("expected %v, got %v", expected, commits)
+		}
+	})
+
+	// 履歴を取得できない場合は、境界より前に変更されたファイルに誤ったコミットを報告しない
+	t.Run("shallow clone without remote", func(t *testing.T) {
+		cloneDir := clone(t)
+		if output, err := exec.Command("git", "-C", cloneDir, "remote", "remove", "origin").CombinedOutput(); err != nil {
+			t.Fatalf("git remote remove failed: %v\n%s", err, output)
+		}
+		commits, err := lastModifiedCommits(cloneDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(commits) != 0 {
+			t.Errorf("expected no commits, got %v", commits)
+		}
+	})
+}
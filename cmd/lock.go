@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// lockFileName は生成内容を記録するファイルの名前で、出力ファイルと同じディレクトリに置きます
+	lockFileName = "mushi.lock"
+	// lockVersion は mushi.lock の形式のバージョンです
+	lockVersion = 1
+)
+
+// Lockfile は mushi.lock の内容です。Files のキーは出力ファイルの名前です
+type Lockfile struct {
+	Version int                   `json:"version"`
+	Files   map[string]*LockEntry `json:"files"`
+}
+
+// LockEntry は1つの出力ファイルの生成内容を記録します。
+// CommonHash はインポートを解決した common.gitignore のハッシュです
+type LockEntry struct {
+	Templates   []LockedTemplate `json:"templates"`
+	CommonHash  string           `json:"common_hash,omitempty"`
+	ContentHash string           `json:"content_hash"`
+	Dedupe      bool             `json:"dedupe"`
+}
+
+// LockedTemplate は生成に使ったテンプレートとその取得元です。
+// Commit はローカルディレクトリのソースでは空になります
+type LockedTemplate struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Commit string `json:"commit,omitempty"`
+	Hash   string `json:"hash"`
+}
+
+// hashContent returns the sha256 digest of content
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// lockPath returns the path of the lockfile for outputPath
+func lockPath(outputPath string) string {
+	return filepath.Join(filepath.Dir(outputPath), lockFileName)
+}
+
+// readLockfile reads the lockfile at path, returning an empty one if it does not exist
+func readLockfile(path string) (*Lockfile, error) {
+	lock := &Lockfile{Version: lockVersion, Files: make(map[string]*LockEntry)}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, lock); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if lock.Version != lockVersion {
+		return nil, fmt.Errorf("unsupported %s version %d", path, lock.Version)
+	}
+	if lock.Files == nil {
+		lock.Files = make(map[string]*LockEntry)
+	}
+	return lock, nil
+}
+
+// write saves the lockfile to path, removing it when no files are recorded
+func (l *Lockfile) write(path string) error {
+	if len(l.Files) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	content, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
+
+// lockTemplate records where the template content came from
+func lockTemplate(sources Sources, name string, content []byte) (LockedTemplate, error) {
+	src, _, err := sources.Lookup(name)
+	if err != nil {
+		return LockedTemplate{}, err
+	}
+
+	locked := LockedTemplate{Name: name, Source: src.Name, Hash: hashContent(content)}
+	if !src.Local {
+		if locked.Commit, err = cacheCommit(src.Dir); err != nil {
+			return LockedTemplate{}, fmt.Errorf("failed to read commit of source %s: %w", src.Name, err)
+		}
+		if !isFullCommit(locked.Commit) {
+			return LockedTemplate{}, fmt.Errorf("source %s is at an unknown commit %q", src.Name, locked.Commit)
+		}
+	}
+	return locked, nil
+}
+
+// recordLock updates the lockfile next to outputPath with the state of doc.
+// rendered はこの実行でキャッシュから読み込んだテンプレートで、それ以外の区画は以前の記録を引き継ぎます
+func recordLock(outputPath string, content []byte, doc *Document, sources Sources, configDir string, rendered []Section, dedupe bool) error {
+	path := lockPath(outputPath)
+	lock, err := readLockfile(path)
+	if err != nil {
+		return err
+	}
+	key := filepath.Base(outputPath)
+
+	previous := make(map[string]LockedTemplate)
+	if old, ok := lock.Files[key]; ok {
+		for _, t := range old.Templates {
+			previous[t.Name] = t
+		}
+	}
+	fresh := make(map[string][]byte)
+	for _, s := range rendered {
+		fresh[s.Name] = s.Content
+	}
+
+	entry := &LockEntry{ContentHash: hashContent(content), Dedupe: dedupe}
+	for _, s := range doc.Sections() {
+		if s.Name == commonSectionName {
+			// 重複除去前の、インポートを解決した common.gitignore を記録する
+			common, err := loadCommon(configDir, sources)
+			if err != nil {
+				return err
+			}
+			entry.CommonHash = hashContent(common)
+			continue
+		}
+
+		raw, ok := fresh[s.Name]
+		if !ok {
+			if t, ok := previous[s.Name]; ok {
+				entry.Templates = append(entry.Templates, t)
+				continue
+			}
+			// 記録の無い区画は現在のキャッシュから記録する
+			sections, err := loadTemplates(sources, []string{s.Name})
+			if err != nil {
+				return err
+			}
+			raw = sections[0].Content
+		}
+
+		t, err := lockTemplate(sources, s.Name, raw)
+		if err != nil {
+			return err
+		}
+		entry.Templates = append(entry.Templates, t)
+	}
+
+	if len(doc.Sections()) == 0 {
+		delete(lock.Files, key)
+	} else {
+		lock.Files[key] = entry
+	}
+	return lock.write(path)
+}
+
+// lockedDedupe reports whether the recorded file was generated with de-duplication
+func lockedDedupe(outputPath string) bool {
+	lock, err := readLockfile(lockPath(outputPath))
+	if err != nil {
+		return true
+	}
+	if entry, ok := lock.Files[filepath.Base(outputPath)]; ok {
+		return entry.Dedupe
+	}
+	return true
+}
+
+// templateAtCommit reads a locked template as it was at the recorded commit.
+// キャッシュの作業ツリーは変更せず、必要ならそのコミットだけを fetch します
+func templateAtCommit(sources Sources, t LockedTemplate) ([]byte, error) {
+	src, ok := sources.find(t.Source)
+	if !ok {
+		return nil, fmt.Errorf("unknown source %s", t.Source)
+	}
+	name := t.Name
+	if _, rest, qualified := strings.Cut(name, ":"); qualified {
+		name = rest
+	}
+	relPath := name + ".gitignore"
+
+	if t.Commit == "" || src.Local {
+		return os.ReadFile(filepath.Join(src.Dir, relPath))
+	}
+	if !isFullCommit(t.Commit) {
+		return nil, fmt.Errorf("locked commit %q is not a commit SHA", t.Commit)
+	}
+	if head, err := cacheCommit(src.Dir); err == nil && head == t.Commit {
+		return os.ReadFile(filepath.Join(src.Dir, relPath))
+	}
+	if isSnapshot(src.Dir) {
+		return nil, fmt.Errorf("source %s is an embedded snapshot and cannot provide commit %s", src.Name, t.Commit)
+	}
+
+	if exec.Command("git", "-C", src.Dir, "cat-file", "-e", t.Commit+"^{commit}").Run() != nil {
+		fmt.Printf("Fetching %s from %s...\n", t.Commit, src.Name)
+		if err := runGit(src.Dir, "fetch", "--depth", "1", "origin", t.Commit); err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", t.Commit, err)
+		}
+	}
+	content, err := exec.Command("git", "-C", src.Dir, "show", t.Commit+":"+relPath).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at %s: %w", relPath, t.Commit, err)
+	}
+	return content, nil
+}
+
+// verifyFile regenerates content from entry and reports every way it has drifted.
+// 再生成した内容も返すので、差分の表示に使えます
+func verifyFile(content []byte, entry *LockEntry, sources Sources, configDir string) ([]string, []byte, error) {
+	var problems []string
+	if hashContent(content) != entry.ContentHash {
+		problems = append(problems, "file content does not match the recorded hash")
+	}
+
+	doc, err := ParseDocument(content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 区画の構成を確認
+	locked := make(map[string]bool)
+	for _, t := range entry.Templates {
+		locked[t.Name] = true
+		if !doc.Has(t.Name) {
+			problems = append(problems, fmt.Sprintf("template %s is locked but missing from the file", t.Name))
+		}
+	}
+	for _, s := range doc.Sections() {
+		switch {
+		case s.Name == commonSectionName:
+			if entry.CommonHash == "" {
+				problems = append(problems, "common.gitignore section is not in the lock")
+			}
+		case !locked[s.Name]:
+			problems = append(problems, fmt.Sprintf("template %s is not in the lock", s.Name))
+		}
+	}
+	if entry.CommonHash != "" && !doc.Has(commonSectionName) {
+		problems = append(problems, "common.gitignore section is locked but missing from the file")
+	}
+
+	// 現在の common.gitignore とそのインポートから共通区画を再生成
+	if entry.CommonHash != "" {
+		common, err := loadCommon(configDir, sources)
+		if err != nil {
+			return nil, nil, err
+		}
+		if hashContent(common) != entry.CommonHash {
+			problems = append(problems, "common.gitignore does not match the recorded hash")
+		}
+		if doc.Has(commonSectionName) {
+			doc.Upsert(Section{Name: commonSectionName, Content: common})
+		}
+	}
+
+	// 記録されたコミットのテンプレートで再生成
+	for _, t := range entry.Templates {
+		raw, err := templateAtCommit(sources, t)
+		if err != nil {
+			return nil, nil, fmt.Errorf("template %s: %w", t.Name, err)
+		}
+		// インポートは現在のソースから展開する
+		_, templatePath, _ := sources.Lookup(t.Name)
+		if raw, err = renderTemplate(sources, t.Name, templatePath, raw); err != nil {
+			return nil, nil, err
+		}
+		if hashContent(raw) != t.Hash {
+			problems = append(problems, fmt.Sprintf("template %s in source %s does not match the recorded hash", t.Name, t.Source))
+		}
+		doc.Upsert(Section{Name: t.Name, Content: raw})
+	}
+	// append と同じく、既にファイルにある共通パターンは共通区画から除く
+	doc.dedupeCommon()
+	if entry.Dedupe {
+		doc.Dedupe()
+	}
+
+	regenerated := doc.Bytes()
+	if string(regenerated) != string(content) {
+		problems = append(problems, "file differs from the content regenerated from the lock")
+	}
+	return problems, regenerated, nil
+}
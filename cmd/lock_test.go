@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndVerifyLock(t *testing.T) {
+	tmpDir := t.TempDir()
+	templatesDir := filepath.Join(tmpDir, "templates")
+	if err := os.MkdirAll(templatesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTemplate := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(templatesDir, name+".gitignore"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTemplate("Go", "bin/\n*.log\n")
+	writeTemplate("Node", "node_modules/\n")
+
+	configDir := filepath.Join(tmpDir, "config")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeCommon := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(configDir, "common.gitignore"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeCommon("*.log\n")
+
+	sources := Sources{{Name: "local", Dir: templatesDir, Local: true}}
+	outputPath := filepath.Join(tmpDir, ".gitignore")
+
+	// create と同じ手順で生成して記録する
+	doc, sections, err := composeDocument(sources, configDir, []string{"Go", "Node"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := doc.Bytes()
+	if err := os.WriteFile(outputPath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordLock(outputPath, content, doc, sources, configDir, sections, true); err != nil {
+		t.Fatalf("recordLock failed: %v", err)
+	}
+
+	lock, err := readLockfile(filepath.Join(tmpDir, lockFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := lock.Files[".gitignore"]
+	if !ok {
+		t.Fatal("lock entry for .gitignore not found")
+	}
+	if len(entry.Templates) != 2 || entry.Templates[0].Name != "Go" || entry.Templates[0].Source != "local" {
+		t.Errorf("unexpected templates: %+v", entry.Templates)
+	}
+	if entry.CommonHash != hashContent([]byte("*.log\n")) || !entry.Dedupe {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+
+	t.Run("matches when unchanged", func(t *testing.T) {
+		problems, _, err := verifyFile(content, entry, sources, configDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(problems) != 0 {
+			t.Errorf("expected no problems, got %v", problems)
+		}
+	})
+
+	t.Run("detects edits inside a section", func(t *testing.T) {
+		edited := strings.Replace(string(content), "node_modules/\n", "node_modules/\ndist/\n", 1)
+		problems, regenerated, err := verifyFile([]byte(edited), entry, sources, configDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(problems) != 2 {
+			t.Errorf("expected hash and regeneration problems, got %v", problems)
+		}
+		if string(regenerated) != string(content) {
+			t.Errorf("expected regenerated content to match the original:\n%q\ngot:\n%q", content, regenerated)
+		}
+	})
+
+	t.Run("detects template changes", func(t *testing.T) {
+		writeTemplate("Node", "node_modules/\n.next/\n")
+		defer writeTemplate("Node", "node_modules/\n")
+
+		problems, _, err := verifyFile(content, entry, sources, configDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(problems) != 2 {
+			t.Errorf("expected template hash and regeneration problems, got %v", problems)
+		}
+	})
+
+	t.Run("detects common.gitignore changes", func(t *testing.T) {
+		writeCommon("*.log\n#Include: ./extra.gitignore\n")
+		if err := os.WriteFile(filepath.Join(configDir, "extra.gitignore"), []byte(".env\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		defer writeCommon("*.log\n")
+
+		problems, regenerated, err := verifyFile(content, entry, sources, configDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(problems) != 2 {
+			t.Errorf("expected common hash and regeneration problems, got %v", problems)
+		}
+		if !strings.Contains(string(regenerated), ".env\n") {
+			t.Errorf("expected regenerated content to include the imported file, got:\n%s", regenerated)
+		}
+	})
+
+	t.Run("removing every section deletes the lock", func(t *testing.T) {
+		if err := removeSections(doc, []string{"Go", "Node"}, false); err != nil {
+			t.Fatal(err)
+		}
+		if err := recordLock(outputPath, doc.Bytes(), doc, sources, configDir, nil, true); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, lockFileName)); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed, got %v", lockFileName, err)
+		}
+	})
+}
+
+func TestVerifyAppendWithoutDedupe(t *testing.T) {
+	tmpDir := t.TempDir()
+	templatesDir := filepath.Join(tmpDir, "templates")
+	configDir := filepath.Join(tmpDir, "config")
+	for _, dir := range []string{templatesDir, configDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(templatesDir, "Go.gitignore"), []byte("bin/\n.DS_Store\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "common.gitignore"), []byte(".DS_Store\n.env\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sources := Sources{{Name: "local", Dir: templatesDir, Local: true}}
+	outputPath := filepath.Join(tmpDir, ".gitignore")
+
+	// 手書きの .DS_Store があるファイルに --no-dedupe で追記する
+	doc, err := ParseDocument([]byte(".DS_Store\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sections, err := composeAppend(doc, sources, configDir, []string{"Go"}, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := doc.Bytes()
+	if strings.Count(string(content), ".DS_Store") != 2 {
+		t.Errorf("expected the common section to skip .DS_Store, got:\n%s", content)
+	}
+	if err := recordLock(outputPath, content, doc, sources, configDir, sections, false); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := readLockfile(lockPath(outputPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems, regenerated, err := verifyFile(content, lock.Files[".gitignore"], sources, configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("expected no problems, got %v\n%s", problems, unifiedDiff(content, regenerated, "file", "regenerated"))
+	}
+
+	// sync でも共通区画は同じ内容になる
+	var rendered []Section
+	syncDocument(doc, sectionRenderer(sources, configDir, &rendered), false)
+	if string(doc.Bytes()) != string(content) {
+		t.Errorf("sync changed the file:\n%s", unifiedDiff(content, doc.Bytes(), "file", "synced"))
+	}
+}
@@ -0,0 +1,287 @@
+package cmd
+
+import (
+	"strings"
+	"unicode"
+)
+
+// IgnoreRule は .gitignore の1行を解析したパターンです
+type IgnoreRule struct {
+	// Pattern は元の行から末尾の空白を取り除いたものです
+	Pattern string
+	// Line は元のファイルでの行番号（1 始まり）です
+	Line int
+	// Negate は "!" で始まる再包含のパターンであることを示します
+	Negate bool
+	// DirOnly は末尾が "/" でディレクトリにのみ一致することを示します
+	DirOnly bool
+	// Anchored は途中または先頭に "/" を含み、.gitignore の場所を基準に一致することを示します
+	Anchored bool
+
+	segments []string
+}
+
+// Matcher は .gitignore のパターンを git と同じ規則で評価します
+type Matcher struct {
+	Rules []IgnoreRule
+}
+
+// NewMatcher parses gitignore content into a matcher
+func NewMatcher(content []byte) *Matcher {
+	m := &Matcher{}
+	for i, line := range strings.Split(string(content), "\n") {
+		if rule, ok := parseIgnoreRule(line); ok {
+			rule.Line = i + 1
+			m.Rules = append(m.Rules, rule)
+		}
+	}
+	return m
+}
+
+// parseIgnoreRule parses one line, reporting false for blank lines and comments
+func parseIgnoreRule(line string) (IgnoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return IgnoreRule{}, false
+	}
+	line = trimUnescapedSpace(line)
+	if line == "" {
+		return IgnoreRule{}, false
+	}
+
+	rule := IgnoreRule{Pattern: line}
+	p := line
+	if strings.HasPrefix(p, "!") {
+		rule.Negate = true
+		p = p[1:]
+	}
+	if strings.HasSuffix(p, "/") && !strings.HasSuffix(p, "\\/") {
+		rule.DirOnly = true
+		p = strings.TrimRight(p, "/")
+	}
+	if p == "" {
+		return IgnoreRule{}, false
+	}
+
+	// 先頭または途中に "/" があれば .gitignore の場所に固定される
+	if strings.Contains(p, "/") {
+		rule.Anchored = true
+		p = strings.TrimPrefix(p, "/")
+	}
+	rule.segments = strings.Split(p, "/")
+	return rule, true
+}
+
+// trimUnescapedSpace removes trailing spaces that are not escaped with a backslash
+func trimUnescapedSpace(line string) string {
+	for strings.HasSuffix(line, " ") {
+		// 直前のバックスラッシュの数が奇数ならエスケープされている
+		backslashes := 0
+		for i := len(line) - 2; i >= 0 && line[i] == '\\'; i-- {
+			backslashes++
+		}
+		if backslashes%2 == 1 {
+			break
+		}
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+// Match reports whether path is ignored and which rule decided it.
+// path は .gitignore のあるディレクトリからの "/" 区切りの相対パスです。
+// 親ディレクトリが除外されている場合、その中のパスは再包含できません
+func (m *Matcher) Match(path string, isDir bool) (*IgnoreRule, bool) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil, false
+	}
+
+	parts := strings.Split(path, "/")
+	for i := 1; i < len(parts); i++ {
+		if rule, ignored := m.matchPath(parts[:i], true); ignored {
+			return rule, true
+		}
+	}
+	return m.matchPath(parts, isDir)
+}
+
+// matchPath finds the last rule matching the path without looking at parent directories
+func (m *Matcher) matchPath(parts []string, isDir bool) (*IgnoreRule, bool) {
+	for i := len(m.Rules) - 1; i >= 0; i-- {
+		rule := &m.Rules[i]
+		if rule.matches(parts, isDir) {
+			return rule, !rule.Negate
+		}
+	}
+	return nil, false
+}
+
+// matches reports whether the rule matches the path segments
+func (r *IgnoreRule) matches(parts []string, isDir bool) bool {
+	if r.DirOnly && !isDir {
+		return false
+	}
+	if !r.Anchored {
+		return matchSegment(r.segments[0], parts[len(parts)-1])
+	}
+	return matchSegments(r.segments, parts)
+}
+
+// matchSegments matches pattern segments against path segments, expanding "**"
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			// 末尾の "/**" は中身すべてに一致し、ディレクトリ自身には一致しない
+			if len(rest) == 0 {
+				return len(parts) > 0
+			}
+			// "**/" は0個以上のディレクトリに一致する
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(rest, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 || !matchSegment(pattern[0], parts[0]) {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// matchSegment matches a single path segment against a glob without "/".
+// "*" と "?" はスラッシュ以外に、"[...]" は文字クラスに一致し、"\" は次の文字をエスケープします
+func matchSegment(pattern, name string) bool {
+	p := []rune(pattern)
+	n := []rune(name)
+	pi, ni := 0, 0
+	starP, starN := -1, -1
+
+	for ni < len(n) {
+		if pi < len(p) {
+			switch p[pi] {
+			case '*':
+				// 連続する "*" は1つの "*" として扱う
+				for pi < len(p) && p[pi] == '*' {
+					pi++
+				}
+				starP, starN = pi, ni
+				continue
+			case '?':
+				pi++
+				ni++
+				continue
+			case '[':
+				if matched, next, ok := matchClass(p, pi, n[ni]); ok {
+					if matched {
+						pi = next
+						ni++
+						continue
+					}
+				} else if n[ni] == '[' {
+					// 閉じていない "[" はそのままの文字として扱う
+					pi++
+					ni++
+					continue
+				}
+			case '\\':
+				if pi+1 < len(p) && p[pi+1] == n[ni] {
+					pi += 2
+					ni++
+					continue
+				}
+			default:
+				if p[pi] == n[ni] {
+					pi++
+					ni++
+					continue
+				}
+			}
+		}
+
+		// 直前の "*" まで戻って1文字多く消費させる
+		if starP < 0 {
+			return false
+		}
+		starN++
+		pi, ni = starP, starN
+	}
+
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+// posixClasses は "[[:name:]]" 形式の文字クラスです
+var posixClasses = map[string]func(rune) bool{
+	"alnum":  func(c rune) bool { return unicode.IsLetter(c) || unicode.IsDigit(c) },
+	"alpha":  unicode.IsLetter,
+	"blank":  func(c rune) bool { return c == ' ' || c == '\t' },
+	"cntrl":  unicode.IsControl,
+	"digit":  unicode.IsDigit,
+	"graph":  func(c rune) bool { return unicode.IsGraphic(c) && !unicode.IsSpace(c) },
+	"lower":  unicode.IsLower,
+	"print":  unicode.IsPrint,
+	"punct":  unicode.IsPunct,
+	"space":  unicode.IsSpace,
+	"upper":  unicode.IsUpper,
+	"xdigit": func(c rune) bool { return strings.ContainsRune("0123456789abcdefABCDEF", c) },
+}
+
+// matchClass matches c against the bracket expression starting at p[start].
+// ok が false の場合は "[" が閉じておらず、文字クラスではありません
+func matchClass(p []rune, start int, c rune) (matched bool, next int, ok bool) {
+	i := start + 1
+	negate := false
+	if i < len(p) && (p[i] == '!' || p[i] == '^') {
+		negate = true
+		i++
+	}
+
+	first := true
+	for i < len(p) {
+		if p[i] == ']' && !first {
+			return matched != negate, i + 1, true
+		}
+		first = false
+
+		// [:class:]
+		if p[i] == '[' && i+1 < len(p) && p[i+1] == ':' {
+			rest := string(p[i+2:])
+			if end := strings.Index(rest, ":]"); end >= 0 {
+				name := rest[:end]
+				if fn, known := posixClasses[name]; known {
+					matched = matched || fn(c)
+					i += 2 + len([]rune(name)) + 2
+					continue
+				}
+			}
+		}
+
+		lo := p[i]
+		if lo == '\\' && i+1 < len(p) {
+			i++
+			lo = p[i]
+		}
+		i++
+
+		hi := lo
+		if i+1 < len(p) && p[i] == '-' && p[i+1] != ']' {
+			hi = p[i+1]
+			i += 2
+			if hi == '\\' && i < len(p) {
+				hi = p[i]
+				i++
+			}
+		}
+		if lo <= c && c <= hi {
+			matched = true
+		}
+	}
+	return false, 0, false
+}
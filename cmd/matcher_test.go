@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestMatchSegment(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{pattern: "*.log", name: "debug.log", expected: true},
+		{pattern: "*.log", name: "debug.txt", expected: false},
+		{pattern: "*", name: "anything", expected: true},
+		{pattern: "a*b*c", name: "aXXbYYc", expected: true},
+		{pattern: "a*b*c", name: "aXXbYY", expected: false},
+		{pattern: "?.txt", name: "a.txt", expected: true},
+		{pattern: "?.txt", name: "ab.txt", expected: false},
+		{pattern: "*.py[cod]", name: "x.pyc", expected: true},
+		{pattern: "*.py[cod]", name: "x.pyx", expected: false},
+		{pattern: "[!a]*", name: "abc", expected: false},
+		{pattern: "[^a]*", name: "bcd", expected: true},
+		{pattern: "[a-c]x", name: "bx", expected: true},
+		{pattern: "[a-c]x", name: "dx", expected: false},
+		{pattern: "[._]*.sw[a-p]", name: ".main.swp", expected: true},
+		{pattern: "[[:digit:]]*", name: "1abc", expected: true},
+		{pattern: "[[:digit:]]*", name: "abc", expected: false},
+		{pattern: "[Dd]esktop.ini", name: "desktop.ini", expected: true},
+		{pattern: "\\#file", name: "#file", expected: true},
+		{pattern: "\\*", name: "*", expected: true},
+		{pattern: "\\*", name: "a", expected: false},
+		{pattern: "[abc", name: "[abc", expected: true},
+		{pattern: "***.log", name: "x.log", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			if got := matchSegment(tt.pattern, tt.name); got != tt.expected {
+				t.Errorf("matchSegment(%q, %q) = %v, expected %v", tt.pattern, tt.name, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseIgnoreRule(t *testing.T) {
+	tests := []struct {
+		line     string
+		ok       bool
+		pattern  string
+		negate   bool
+		dirOnly  bool
+		anchored bool
+	}{
+		{line: "# comment", ok: false},
+		{line: "", ok: false},
+		{line: "   ", ok: false},
+		{line: "*.log", ok: true, pattern: "*.log"},
+		{line: "*.log  ", ok: true, pattern: "*.log"},
+		{line: "foo\\ ", ok: true, pattern: "foo\\ "},
+		{line: "!keep.log", ok: true, pattern: "!keep.log", negate: true},
+		{line: "build/", ok: true, pattern: "build/", dirOnly: true},
+		{line: "/build", ok: true, pattern: "/build", anchored: true},
+		{line: "doc/frotz", ok: true, pattern: "doc/frotz", anchored: true},
+		{line: "\\#hash", ok: true, pattern: "\\#hash"},
+		{line: "\\!bang", ok: true, pattern: "\\!bang"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			rule, ok := parseIgnoreRule(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("parseIgnoreRule(%q) ok = %v, expected %v", tt.line, ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if rule.Pattern != tt.pattern || rule.Negate != tt.negate || rule.DirOnly != tt.dirOnly || rule.Anchored != tt.anchored {
+				t.Errorf("parseIgnoreRule(%q) = %+v", tt.line, rule)
+			}
+		})
+	}
+}
+
+func TestMatcherMatch(t *testing.T) {
+	content := `# comment
+*.log
+!important.log
+/root-only
+build/
+doc/*.txt
+**/cache
+logs/**
+a/**/b
+vendor/
+!vendor/keep
+\#literal
+`
+	m := NewMatcher([]byte(content))
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+		line    int
+	}{
+		{path: "debug.log", ignored: true, line: 2},
+		{path: "nested/dir/debug.log", ignored: true, line: 2},
+		{path: "important.log", ignored: false, line: 3},
+		{path: "root-only", ignored: true, line: 4},
+		{path: "sub/root-only", ignored: false},
+		{path: "build", isDir: true, ignored: true, line: 5},
+		{path: "build", isDir: false, ignored: false},
+		{path: "build/output.bin", ignored: true, line: 5},
+		{path: "src/build/x", ignored: true, line: 5},
+		{path: "doc/notes.txt", ignored: true, line: 6},
+		{path: "doc/sub/notes.txt", ignored: false},
+		{path: "cache", isDir: true, ignored: true, line: 7},
+		{path: "deep/er/cache", ignored: true, line: 7},
+		{path: "logs", isDir: true, ignored: false},
+		{path: "logs/today", ignored: true, line: 8},
+		{path: "a/b", ignored: true, line: 9},
+		{path: "a/x/y/b", ignored: true, line: 9},
+		{path: "a/x/y/c", ignored: false},
+		{path: "vendor/keep", ignored: true, line: 10},
+		{path: "#literal", ignored: true, line: 12},
+		{path: "src/main.go", ignored: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rule, ignored := m.Match(tt.path, tt.isDir)
+			if ignored != tt.ignored {
+				t.Errorf("Match(%q) ignored = %v, expected %v (rule %+v)", tt.path, ignored, tt.ignored, rule)
+			}
+			if tt.line != 0 && (rule == nil || rule.Line != tt.line) {
+				t.Errorf("Match(%q) rule = %+v, expected line %d", tt.path, rule, tt.line)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// removeSections deletes the named template sections from doc.
+// 他に common.gitignore を必要とするテンプレート区画が残らない場合は、共通区画も削除します
+func removeSections(doc *Document, names []string, keepCommon bool) error {
+	for _, name := range names {
+		if name == commonSectionName {
+			return fmt.Errorf("%s cannot be removed directly", commonSectionName)
+		}
+		if !doc.Has(name) {
+			return fmt.Errorf("template %s is not in the file", name)
+		}
+	}
+	for _, name := range names {
+		doc.Remove(name)
+	}
+
+	if keepCommon {
+		return nil
+	}
+	for _, s := range doc.Sections() {
+		if s.Name != commonSectionName {
+			return nil
+		}
+	}
+	doc.Remove(commonSectionName)
+	return nil
+}
+
+// removeTemplates deletes the named sections from doc, re-rendering the rest when dedupe is set.
+// 削除した区画と重複していたために他の区画から取り除かれたパターンを戻すため、
+// 残りの区画を render で再生成してから重複除去をやり直します
+func removeTemplates(doc *Document, names []string, keepCommon bool, render func(name string) ([]byte, error), dedupe bool) ([]syncResult, error) {
+	if err := removeSections(doc, names, keepCommon); err != nil {
+		return nil, err
+	}
+	if !dedupe || len(doc.Sections()) == 0 {
+		return nil, nil
+	}
+	return syncDocument(doc, render, true), nil
+}
+
+var removeCmd = &cobra.Command{
+	Use:   "remove [template...]",
+	Short: "Remove template sections from existing .gitignore",
+	Args:  cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// 既存の出力ファイルを読み込む
+		existingContent, err := os.ReadFile(outputPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", outputPath, err)
+			os.Exit(1)
+		}
+
+		doc, err := ParseDocument(existingContent)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", outputPath, err)
+			os.Exit(1)
+		}
+
+		var templates []string
+		if interactive {
+			// インタラクティブモード（ファイル内の区画から選択）
+			var names []string
+			for _, s := range doc.Sections() {
+				if s.Name != commonSectionName {
+					names = append(names, s.Name)
+				}
+			}
+			if len(names) == 0 {
+				fmt.Printf("No templates found in %s\n", outputPath)
+				return
+			}
+			// プレビューにはファイル内の区画の内容を表示
+			preview := func(name string) (templatePreview, error) {
+				for _, s := range doc.Sections() {
+					if s.Name == name {
+						return templatePreview{content: string(s.Content), source: outputPath}, nil
+					}
+				}
+				return templatePreview{}, fmt.Errorf("section %s not found", name)
+			}
+			selected, err := runSelector("Select templates to remove", names, selectorOptions{preview: preview})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error in interactive mode: %v\n", err)
+				os.Exit(1)
+			}
+			if len(selected) == 0 {
+				fmt.Println("No template selected")
+				return
+			}
+			templates = selected
+		} else {
+			// 非インタラクティブモード
+			if len(args) < 1 {
+				fmt.Fprintln(os.Stderr, "Error: template name is required")
+				os.Exit(1)
+			}
+			templates = args
+		}
+
+		// 残りの区画の再生成と mushi.lock の記録に使うテンプレートの取得元
+		sources, err := getSources()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving template sources: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 設定ディレクトリのパスを解決
+		configDir, err := getConfigDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting config directory: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 残りの区画の再生成に使うキャッシュが存在しない場合はクローン（更新はしない）
+		dedupe := lockedDedupe(outputPath)
+		if dedupe {
+			if err := sources.EnsureCloned(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error managing cache: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		var rendered []Section
+		results, err := removeTemplates(doc, templates, keepCommon, sectionRenderer(sources, configDir, &rendered), dedupe)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, r := range results {
+			if r.Err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: kept %s as is: %v\n", r.Name, r.Err)
+			}
+		}
+		finalContent := doc.Bytes()
+
+		// --print が指定されたら標準出力に表示
+		if print {
+			os.Stdout.Write(finalContent)
+			return
+		}
+
+		// --diff / --dry-run が指定されたら差分のみ表示
+		if showDiff || dryRun {
+			previewDiff(outputPath, finalContent, showDiff)
+			return
+		}
+
+		// 結果を出力ファイルに書き込み
+		if err := os.WriteFile(outputPath, finalContent, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing to %s: %v\n", outputPath, err)
+			os.Exit(1)
+		}
+
+		// mushi.lock に生成内容を記録
+		if !noLock {
+			if err := recordLock(outputPath, finalContent, doc, sources, configDir, rendered, dedupe); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to update %s: %v\n", lockFileName, err)
+			}
+		}
+
+		for _, template := range templates {
+			fmt.Printf("✨️ Successfully removed %s from %s\n", template, outputPath)
+		}
+	},
+}
+
+// removeのみのオプションを記述
+var (
+	keepCommon bool
+)
+
+func init() {
+	removeCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Interactively select templates to remove")
+	removeCmd.Flags().BoolVar(&keepCommon, "keep-common", false, "Keep common.gitignore patterns even when no template needs them")
+	removeCmd.Flags().BoolVar(&print, "print", false, "Print the result to stdout instead of writing to .gitignore")
+	removeCmd.Flags().BoolVar(&showDiff, "diff", false, "Print a unified diff instead of writing, and exit with status 1 if the file would change")
+	removeCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print a unified diff instead of writing")
+	removeCmd.Flags().BoolVar(&noLock, "no-lock", false, "Do not record the result in mushi.lock")
+	removeCmd.Flags().StringVarP(&outputPath, "path", "p", ".gitignore", "Path to output file (default: .gitignore)")
+	RootCmd.AddCommand(removeCmd)
+}
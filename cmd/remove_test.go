@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRemoveSections(t *testing.T) {
+	input := "# >>> mushi: common.gitignore\n.env\n# <<< mushi: common.gitignore\n\n" +
+		"# >>> mushi: Go\nbin/\n# <<< mushi: Go\n\n" +
+		"# >>> mushi: Node\nnode_modules/\n# <<< mushi: Node\n\nlocal/\n"
+
+	tests := []struct {
+		name       string
+		remove     []string
+		keepCommon bool
+		expected   string
+		wantErr    bool
+	}{
+		{
+			name:   "keeps common while another template needs it",
+			remove: []string{"Go"},
+			expected: "# >>> mushi: common.gitignore\n.env\n# <<< mushi: common.gitignore\n\n" +
+				"# >>> mushi: Node\nnode_modules/\n# <<< mushi: Node\n\nlocal/\n",
+		},
+		{
+			name:     "drops common with the last template",
+			remove:   []string{"Go", "Node"},
+			expected: "local/\n",
+		},
+		{
+			name:       "keep-common retains common",
+			remove:     []string{"Go", "Node"},
+			keepCommon: true,
+			expected:   "# >>> mushi: common.gitignore\n.env\n# <<< mushi: common.gitignore\n\nlocal/\n",
+		},
+		{
+			name:    "missing template",
+			remove:  []string{"Rust"},
+			wantErr: true,
+		},
+		{
+			name:    "common cannot be removed directly",
+			remove:  []string{commonSectionName},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ParseDocument([]byte(input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = removeSections(doc, tt.remove, tt.keepCommon)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				if string(doc.Bytes()) != input {
+					t.Error("document should not change on error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("removeSections failed: %v", err)
+			}
+			if string(doc.Bytes()) != tt.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", tt.expected, doc.Bytes())
+			}
+		})
+	}
+}
+
+func TestRemoveTemplatesAfterDedupe(t *testing.T) {
+	templates := map[string]string{
+		commonSectionName: "*.log\n",
+		"Go":              "bin/\n.env\n",
+		"Node":            "node_modules/\n.env\n*.log\n",
+	}
+	render := func(name string) ([]byte, error) {
+		content, ok := templates[name]
+		if !ok {
+			return nil, fmt.Errorf("template %s not found", name)
+		}
+		return []byte(content), nil
+	}
+
+	tests := []struct {
+		name     string
+		remove   []string
+		dedupe   bool
+		expected string
+	}{
+		{
+			name:   "restores patterns the removed section owned",
+			remove: []string{"Go"},
+			dedupe: true,
+			expected: "# >>> mushi: common.gitignore\n*.log\n# <<< mushi: common.gitignore\n\n" +
+				"# >>> mushi: Node\nnode_modules/\n.env\n# <<< mushi: Node\n",
+		},
+		{
+			name:   "dedupes the remaining sections again",
+			remove: []string{"Node"},
+			dedupe: true,
+			expected: "# >>> mushi: common.gitignore\n*.log\n# <<< mushi: common.gitignore\n\n" +
+				"# >>> mushi: Go\nbin/\n.env\n# <<< mushi: Go\n",
+		},
+		{
+			name:   "leaves sections alone without dedupe",
+			remove: []string{"Go"},
+			expected: "# >>> mushi: common.gitignore\n*.log\n# <<< mushi: common.gitignore\n\n" +
+				"# >>> mushi: Node\nnode_modules/\n# <<< mushi: Node\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sections []Section
+			for _, name := range []string{"Go", "Node"} {
+				sections = append(sections, Section{Name: name, Content: []byte(templates[name])})
+			}
+			doc := NewDocument([]byte(templates[commonSectionName]), sections)
+			doc.Dedupe()
+
+			if _, err := removeTemplates(doc, tt.remove, false, render, tt.dedupe); err != nil {
+				t.Fatalf("removeTemplates failed: %v", err)
+			}
+			if string(doc.Bytes()) != tt.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", tt.expected, doc.Bytes())
+			}
+		})
+	}
+}
@@ -23,7 +23,10 @@ var (
 
 // Config は mushi の設定を保持する構造体です
 type Config struct {
-	NoUpdate bool `mapstructure:"no_update"`
+	NoUpdate bool           `mapstructure:"no_update"`
+	Ref      string         `mapstructure:"ref"`
+	Detect   []DetectRule   `mapstructure:"detect"`
+	Sources  []SourceConfig `mapstructure:"sources"`
 }
 
 var config Config
@@ -73,6 +76,27 @@ func createConfigFile() error {
 
 # Whether to skip updating the local cache
 # no_update = false
+
+# Pin the github/gitignore cache to a commit SHA, tag or branch
+# ref = "main"
+
+# Additional template sources (git URL or local repository path)
+# Templates are addressable as "name:Template"; unqualified names are
+# resolved by priority (higher first, github/gitignore is 0)
+# [[sources]]
+# name = "company"
+# url = "git@example.com:company/gitignore-templates.git"
+# priority = 10
+
+# A plain directory of templates, read in place without git
+# [[sources]]
+# name = "dotfiles"
+# path = "~/dotfiles/gitignores"
+
+# Additional rules for "mushi detect" and "mushi create --detect"
+# [[detect]]
+# pattern = "*.proto"
+# template = "community/Protobuf"
 `
 
 	return os.WriteFile(filepath.Join(ConfigDir, "config.toml"), []byte(configContent), 0644)
@@ -101,7 +125,7 @@ func initConfig() {
 		}
 		cacheHome = filepath.Join(home, ".cache")
 	}
-	CacheDir = filepath.Join(cacheHome, "mushi", "github-gitignore")
+	CacheDir = filepath.Join(cacheHome, "mushi", defaultSourceDir)
 
 	// 必要なディレクトリの作成
 	dirs := []string{ConfigDir, filepath.Dir(CacheDir)}
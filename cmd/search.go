@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/spf13/cobra"
+)
+
+// SearchMatch is a template line that matches a search
+type SearchMatch struct {
+	Template string
+	Line     int
+	Text     string
+}
+
+// searchContent returns the lines of content that match query.
+// substring が true なら query を含む行を、false なら query をパスとみなして
+// その扱いを決める規則（git と同じく最後に一致した規則）を返します
+func searchContent(content []byte, query string, substring bool) []SearchMatch {
+	if substring {
+		var matches []SearchMatch
+		for i, line := range strings.Split(string(content), "\n") {
+			if strings.Contains(line, query) {
+				matches = append(matches, SearchMatch{Line: i + 1, Text: strings.TrimSuffix(line, "\r")})
+			}
+		}
+		return matches
+	}
+
+	// 末尾の "/" はディレクトリとして照合する
+	isDir := strings.HasSuffix(query, "/")
+	rule, _ := NewMatcher(content).Match(query, isDir)
+	if rule == nil {
+		return nil
+	}
+	return []SearchMatch{{Line: rule.Line, Text: rule.Pattern}}
+}
+
+// searchTemplates searches every named template in the sources
+func searchTemplates(sources Sources, names []string, query string, substring bool) ([]SearchMatch, error) {
+	var matches []SearchMatch
+	for _, name := range names {
+		templatePath, err := sources.Path(name)
+		if err != nil {
+			return nil, err
+		}
+		content, err := os.ReadFile(templatePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %w", name, err)
+		}
+		for _, m := range searchContent(content, query, substring) {
+			m.Template = name
+			matches = append(matches, m)
+		}
+	}
+	return matches, nil
+}
+
+// contentFilter returns a list filter that keeps the templates whose content matches the term
+func contentFilter(contents map[string][]byte, substring bool) list.FilterFunc {
+	return func(term string, targets []string) []list.Rank {
+		var ranks []list.Rank
+		for i, name := range targets {
+			// 見出しは絞り込みの対象外
+			if name == "" {
+				continue
+			}
+			if len(searchContent(contents[name], term, substring)) > 0 {
+				ranks = append(ranks, list.Rank{Index: i})
+			}
+		}
+		return ranks
+	}
+}
+
+// runSearchSelector opens the picker, filtering templates by their content
+func runSearchSelector(sources Sources, names []string, query string, substring bool) ([]string, error) {
+	contents := make(map[string][]byte, len(names))
+	for _, name := range names {
+		templatePath, err := sources.Path(name)
+		if err != nil {
+			return nil, err
+		}
+		if contents[name], err = os.ReadFile(templatePath); err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %w", name, err)
+		}
+	}
+
+	return runSelector("Search template contents", names, selectorOptions{
+		preview:    sourcesPreview(sources),
+		grouped:    true,
+		filter:     contentFilter(contents, substring),
+		filterText: query,
+	})
+}
+
+var searchCmd = &cobra.Command{
+	Use:   "search <pattern>",
+	Short: "Find the templates that contain a pattern",
+	Long: `Find the templates that contain a pattern.
+
+By default the pattern is treated as a path, and search reports the rule in
+each template that decides whether the path is ignored, using gitignore
+semantics. A trailing "/" matches the path as a directory. With --substring,
+search reports every line that contains the pattern.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if interactive {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		// テンプレートの取得元を解決
+		sources, err := getSources()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving template sources: %v\n", err)
+			os.Exit(1)
+		}
+
+		// キャッシュディレクトリが存在しない場合はクローン（更新はしない）
+		if err := sources.EnsureCloned(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error managing cache: %v\n", err)
+			os.Exit(1)
+		}
+
+		templates, err := sources.Templates()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading cache directory: %v\n", err)
+			os.Exit(1)
+		}
+
+		var query string
+		if len(args) > 0 {
+			query = args[0]
+		}
+
+		// インタラクティブモードでは選んだテンプレート名を1行ずつ出力
+		if interactive {
+			selected, err := runSearchSelector(sources, templates, query, searchSubstring)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error in interactive mode: %v\n", err)
+				os.Exit(1)
+			}
+			for _, name := range selected {
+				fmt.Println(name)
+			}
+			return
+		}
+
+		matches, err := searchTemplates(sources, templates, query, searchSubstring)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(matches) == 0 {
+			fmt.Fprintf(os.Stderr, "No templates match %s\n", query)
+			os.Exit(1)
+		}
+
+		found := make(map[string]bool)
+		for _, m := range matches {
+			fmt.Printf("%s:%d: %s\n", m.Template, m.Line, m.Text)
+			found[m.Template] = true
+		}
+		fmt.Printf("Found %d match(es) in %d template(s)\n", len(matches), len(found))
+	},
+}
+
+// searchのみのオプションを記述
+var (
+	searchSubstring bool
+)
+
+func init() {
+	searchCmd.Flags().BoolVarP(&searchSubstring, "substring", "s", false, "Match lines containing the pattern instead of using gitignore semantics")
+	searchCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Search template contents in the picker and print the selected names")
+	RootCmd.AddCommand(searchCmd)
+}
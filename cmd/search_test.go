@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearchContent(t *testing.T) {
+	content := []byte("# Python\n__pycache__/\n*.py[cod]\nbuild/\n!build/keep\n")
+
+	tests := []struct {
+		name      string
+		query     string
+		substring bool
+		expected  []SearchMatch
+	}{
+		{name: "glob rule", query: "module.pyc", expected: []SearchMatch{{Line: 3, Text: "*.py[cod]"}}},
+		{name: "directory", query: "__pycache__/", expected: []SearchMatch{{Line: 2, Text: "__pycache__/"}}},
+		{name: "inside excluded directory", query: "build/keep", expected: []SearchMatch{{Line: 4, Text: "build/"}}},
+		{name: "pattern as query", query: "*.pyc", expected: []SearchMatch{{Line: 3, Text: "*.py[cod]"}}},
+		{name: "no match", query: "main.py"},
+		{name: "substring", query: "build", substring: true, expected: []SearchMatch{{Line: 4, Text: "build/"}, {Line: 5, Text: "!build/keep"}}},
+		{name: "substring no match", query: "*.pyc", substring: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := searchContent(content, tt.query, tt.substring)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("searchContent(%q) = %+v, expected %+v", tt.query, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSearchSelectorFilter(t *testing.T) {
+	contents := map[string][]byte{
+		"Terraform":     []byte("*.tfstate\n.terraform/\n"),
+		"Go":            []byte("*.exe\n"),
+		"Global/Backup": []byte("*.tfstate.backup\n"),
+	}
+	names := []string{"Terraform", "Go", "Global/Backup"}
+
+	m := newTemplateModel("Search", names, selectorOptions{
+		grouped:    true,
+		filter:     contentFilter(contents, true),
+		filterText: "tfstate",
+	})
+
+	var visible []string
+	for _, it := range m.list.VisibleItems() {
+		if i, ok := it.(item); ok {
+			visible = append(visible, string(i))
+		}
+	}
+	if !reflect.DeepEqual(visible, []string{"Terraform", "Global/Backup"}) {
+		t.Errorf("visible items = %v, expected [Terraform Global/Backup]", visible)
+	}
+	if m.highlighted() != "Terraform" {
+		t.Errorf("highlighted() = %q, expected Terraform", m.highlighted())
+	}
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// markerBegin と markerEnd は mushi が管理する区画の開始・終了を示す行の接頭辞です
+	markerBegin = "# >>> mushi: "
+	markerEnd   = "# <<< mushi: "
+	// commonSectionName は common.gitignore から生成された区画の名前です
+	commonSectionName = "common.gitignore"
+)
+
+// block は .gitignore 内のひと続きのテキストです。
+// name が空の場合はユーザーが手で書いた管理外のテキストを表します
+type block struct {
+	name    string
+	content []byte
+}
+
+// Document は mushi の管理区画と管理外のテキストからなる .gitignore を表します
+type Document struct {
+	blocks []block
+}
+
+// ParseDocument splits content into managed sections and hand-written text
+func ParseDocument(content []byte) (*Document, error) {
+	d := &Document{}
+	var current *block
+	var text []byte
+
+	flushText := func() {
+		if len(text) > 0 {
+			d.blocks = append(d.blocks, block{content: text})
+			text = nil
+		}
+	}
+
+	for i, line := range bytes.SplitAfter(content, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		trimmed := strings.TrimRight(string(line), " \t\r\n")
+		switch {
+		case strings.HasPrefix(trimmed, markerBegin):
+			name := strings.TrimSpace(strings.TrimPrefix(trimmed, markerBegin))
+			if current != nil {
+				return nil, fmt.Errorf("line %d: section %q starts before section %q ends", i+1, name, current.name)
+			}
+			if d.Has(name) {
+				return nil, fmt.Errorf("line %d: duplicate section %q", i+1, name)
+			}
+			flushText()
+			current = &block{name: name}
+		case strings.HasPrefix(trimmed, markerEnd):
+			name := strings.TrimSpace(strings.TrimPrefix(trimmed, markerEnd))
+			if current == nil || current.name != name {
+				return nil, fmt.Errorf("line %d: unexpected end of section %q", i+1, name)
+			}
+			d.blocks = append(d.blocks, *current)
+			current = nil
+		case current != nil:
+			current.content = append(current.content, line...)
+		default:
+			text = append(text, line...)
+		}
+	}
+
+	if current != nil {
+		return nil, fmt.Errorf("section %q is not terminated", current.name)
+	}
+	flushText()
+	return d, nil
+}
+
+// readDocument reads and parses the file at path
+func readDocument(path string) ([]byte, *Document, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	doc, err := ParseDocument(content)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return content, doc, nil
+}
+
+// NewDocument builds a document from the resolved common content and template sections
+func NewDocument(common []byte, sections []Section) *Document {
+	d := &Document{}
+	if len(common) > 0 {
+		d.Upsert(Section{Name: commonSectionName, Content: common})
+	}
+	for _, s := range sections {
+		d.Upsert(s)
+	}
+	return d
+}
+
+// Has reports whether the document contains a managed section with the given name
+func (d *Document) Has(name string) bool {
+	return d.index(name) >= 0
+}
+
+// Sections returns the managed sections in the order they appear
+func (d *Document) Sections() []Section {
+	var sections []Section
+	for _, b := range d.blocks {
+		if b.name != "" {
+			sections = append(sections, Section{Name: b.name, Content: b.content})
+		}
+	}
+	return sections
+}
+
+// Upsert replaces the section with the same name in place, or adds it at the end
+func (d *Document) Upsert(s Section) {
+	content := s.Content
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		content = append(append([]byte{}, content...), '\n')
+	}
+
+	if i := d.index(s.Name); i >= 0 {
+		d.blocks[i].content = content
+		return
+	}
+
+	// 直前のテキストとの間に空行を挟む
+	if n := len(d.blocks); n > 0 {
+		last := &d.blocks[n-1]
+		switch {
+		case last.name != "":
+			d.blocks = append(d.blocks, block{content: []byte("\n")})
+		case isBlank(*last):
+			// 既に空行で区切られている
+		case !bytes.HasSuffix(last.content, []byte("\n")):
+			last.content = append(last.content, '\n', '\n')
+		case !bytes.HasSuffix(last.content, []byte("\n\n")):
+			last.content = append(last.content, '\n')
+		}
+	}
+	d.blocks = append(d.blocks, block{name: s.Name, content: content})
+}
+
+// Remove deletes the named section and reports whether it existed
+func (d *Document) Remove(name string) bool {
+	i := d.index(name)
+	if i < 0 {
+		return false
+	}
+	d.blocks = append(d.blocks[:i], d.blocks[i+1:]...)
+
+	// 区画の前後に残る余分な空行を取り除く
+	var prev, next *block
+	if i > 0 {
+		prev = &d.blocks[i-1]
+	}
+	if i < len(d.blocks) {
+		next = &d.blocks[i]
+	}
+	prevEndsBlank := prev == nil || (prev.name == "" && (isBlank(*prev) || bytes.HasSuffix(prev.content, []byte("\n\n"))))
+	switch {
+	case next != nil && next.name == "" && prevEndsBlank:
+		next.content = bytes.TrimLeft(next.content, "\n")
+		if len(next.content) == 0 {
+			d.blocks = append(d.blocks[:i], d.blocks[i+1:]...)
+		}
+	case next == nil && prev != nil && prev.name == "":
+		prev.content = bytes.TrimRight(prev.content, "\n")
+		if len(prev.content) == 0 {
+			d.blocks = d.blocks[:i-1]
+		} else {
+			prev.content = append(prev.content, '\n')
+		}
+	}
+	return true
+}
+
+// Bytes renders the document back into .gitignore content
+func (d *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	for _, b := range d.blocks {
+		if b.name == "" {
+			buf.Write(b.content)
+			continue
+		}
+		buf.WriteString(markerBegin + b.name + "\n")
+		buf.Write(b.content)
+		buf.WriteString(markerEnd + b.name + "\n")
+	}
+	return buf.Bytes()
+}
+
+// index returns the position of the named managed section, or -1
+func (d *Document) index(name string) int {
+	for i, b := range d.blocks {
+		if b.name != "" && b.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// isBlank reports whether b is hand-written text consisting only of whitespace
+func isBlank(b block) bool {
+	return b.name == "" && len(bytes.TrimSpace(b.content)) == 0
+}
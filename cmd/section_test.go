@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestParseDocument(t *testing.T) {
+	t.Run("round trips content", func(t *testing.T) {
+		input := "# my rules\n*.tmp\n\n# >>> mushi: Go\nbin/\n# <<< mushi: Go\n\nlocal/\n"
+		doc, err := ParseDocument([]byte(input))
+		if err != nil {
+			t.Fatalf("ParseDocument failed: %v", err)
+		}
+		if string(doc.Bytes()) != input {
+			t.Errorf("expected:\n%q\ngot:\n%q", input, doc.Bytes())
+		}
+
+		sections := doc.Sections()
+		if len(sections) != 1 || sections[0].Name != "Go" || string(sections[0].Content) != "bin/\n" {
+			t.Errorf("unexpected sections: %+v", sections)
+		}
+	})
+
+	errorCases := []struct {
+		name  string
+		input string
+	}{
+		{name: "unterminated", input: "# >>> mushi: Go\nbin/\n"},
+		{name: "mismatched end", input: "# >>> mushi: Go\nbin/\n# <<< mushi: Node\n"},
+		{name: "nested", input: "# >>> mushi: Go\n# >>> mushi: Node\n"},
+		{name: "duplicate", input: "# >>> mushi: Go\n# <<< mushi: Go\n# >>> mushi: Go\n# <<< mushi: Go\n"},
+	}
+	for _, tt := range errorCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseDocument([]byte(tt.input)); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestDocumentUpsert(t *testing.T) {
+	t.Run("replaces existing section in place", func(t *testing.T) {
+		input := "*.tmp\n\n# >>> mushi: Go\nbin/\n# <<< mushi: Go\n\nlocal/\n"
+		doc, err := ParseDocument([]byte(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc.Upsert(Section{Name: "Go", Content: []byte("bin/\n*.exe\n")})
+
+		expected := "*.tmp\n\n# >>> mushi: Go\nbin/\n*.exe\n# <<< mushi: Go\n\nlocal/\n"
+		if string(doc.Bytes()) != expected {
+			t.Errorf("expected:\n%q\ngot:\n%q", expected, doc.Bytes())
+		}
+	})
+
+	t.Run("is idempotent", func(t *testing.T) {
+		doc, err := ParseDocument([]byte("*.tmp\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc.Upsert(Section{Name: "Go", Content: []byte("bin/")})
+		first := doc.Bytes()
+
+		doc, err = ParseDocument(first)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc.Upsert(Section{Name: "Go", Content: []byte("bin/")})
+
+		expected := "*.tmp\n\n# >>> mushi: Go\nbin/\n# <<< mushi: Go\n"
+		if string(first) != expected || string(doc.Bytes()) != expected {
+			t.Errorf("expected:\n%q\ngot:\n%q then %q", expected, first, doc.Bytes())
+		}
+	})
+}
+
+func TestNewDocument(t *testing.T) {
+	doc := NewDocument([]byte(".env\n"), []Section{
+		{Name: "Go", Content: []byte("bin/\n")},
+		{Name: "Node", Content: []byte("node_modules/\n")},
+	})
+
+	expected := "# >>> mushi: common.gitignore\n.env\n# <<< mushi: common.gitignore\n\n" +
+		"# >>> mushi: Go\nbin/\n# <<< mushi: Go\n\n" +
+		"# >>> mushi: Node\nnode_modules/\n# <<< mushi: Node\n"
+	if string(doc.Bytes()) != expected {
+		t.Errorf("expected:\n%q\ngot:\n%q", expected, doc.Bytes())
+	}
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+// show の色付け
+var (
+	showCommentStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+	showOriginStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true)
+	showMarkerStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Bold(true)
+	showNegateStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	showDirStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+)
+
+// highlightIgnore colors gitignore content line by line.
+// 端末以外に出力する場合、lipgloss は装飾を付けずにそのまま返します
+func highlightIgnore(content []byte) string {
+	lines := strings.Split(string(content), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, markerBegin), strings.HasPrefix(trimmed, markerEnd):
+			lines[i] = showMarkerStyle.Render(line)
+		case strings.HasPrefix(trimmed, "# Imported from "), strings.HasPrefix(trimmed, "# End of "):
+			lines[i] = showOriginStyle.Render(line)
+		case strings.HasPrefix(trimmed, "#"):
+			lines[i] = showCommentStyle.Render(line)
+		case strings.HasPrefix(trimmed, "!"):
+			lines[i] = showNegateStyle.Render(line)
+		case strings.HasSuffix(trimmed, "/"):
+			lines[i] = showDirStyle.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+var showCmd = &cobra.Command{
+	Use:   "show [template]",
+	Short: "Print a template or common.gitignore with imports expanded",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if showCommon {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		// テンプレートの取得元を解決
+		sources, err := getSources()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving template sources: %v\n", err)
+			os.Exit(1)
+		}
+
+		// キャッシュディレクトリが存在しない場合はクローン（更新はしない）
+		if err := sources.EnsureCloned(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error managing cache: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 表示するファイルを決める
+		var name, path string
+		var frame importFrame
+		if showCommon {
+			name = "common.gitignore"
+			configDir, err := getConfigDir()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error getting config directory: %v\n", err)
+				os.Exit(1)
+			}
+			if path, err = EnsureCommonIgnore(configDir); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to manage common.gitignore: %v\n", err)
+				os.Exit(1)
+			}
+			frame = importFrame{name: name, path: path, allowFiles: true}
+		} else {
+			name = args[0]
+			if _, path, err = sources.Lookup(name); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			frame = templateFrame(sources, name, path)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", path, err)
+			os.Exit(1)
+		}
+
+		// インポートを展開し、取り込み元をコメントで示す
+		resolved, err := expandImports(content, sources, true, frame)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving imports in %s: %v\n", filepath.Base(path), err)
+			os.Exit(1)
+		}
+
+		// 展開で付く末尾の余分な改行を取り除く
+		resolved = bytes.TrimRight(resolved, "\n")
+		if len(resolved) == 0 {
+			return
+		}
+		fmt.Println(highlightIgnore(resolved))
+	},
+}
+
+// showのみのオプションを記述
+var (
+	showCommon bool
+)
+
+func init() {
+	showCmd.Flags().BoolVar(&showCommon, "common", false, "Show common.gitignore with its imports resolved")
+	RootCmd.AddCommand(showCmd)
+}
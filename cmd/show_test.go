@@ -0,0 +1,13 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestHighlightIgnorePlain(t *testing.T) {
+	// テストの出力先は端末ではないため、装飾は付かない
+	content := "# comment\n# Imported from Go (github: /cache/Go.gitignore)\nbin/\n!keep\n*.log\n"
+	if got := highlightIgnore([]byte(content)); got != content {
+		t.Errorf("highlightIgnore() = %q, expected the content unchanged", got)
+	}
+}
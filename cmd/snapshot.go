@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// オフライン用の github/gitignore テンプレートのスナップショット
+// snapshot_gen.go で生成します
+//
+//go:generate go run snapshot_gen.go -out snapshot.tar.gz
+//go:embed snapshot.tar.gz
+var snapshotArchive []byte
+
+// snapshotMarker は展開したスナップショットのキャッシュに置くファイルで、元のコミットを記録します
+const snapshotMarker = ".mushi-snapshot"
+
+// readSnapshot calls fn for every entry in the embedded snapshot
+func readSnapshot(fn func(name string, content []byte) error) error {
+	gz, err := gzip.NewReader(bytes.NewReader(snapshotArchive))
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		if err := fn(header.Name, content); err != nil {
+			return err
+		}
+	}
+}
+
+// snapshotCommit returns the github/gitignore commit the embedded snapshot was built from
+func snapshotCommit() (string, error) {
+	commit := ""
+	err := readSnapshot(func(name string, content []byte) error {
+		if name == ".commit" {
+			commit = strings.TrimSpace(string(content))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if commit == "" {
+		return "", fmt.Errorf("snapshot has no commit information")
+	}
+	return commit, nil
+}
+
+// extractSnapshot writes the embedded templates to dir.
+// 途中で失敗しても壊れたキャッシュが残らないよう、一時ディレクトリに展開してから移動します
+func extractSnapshot(dir string) error {
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dir), ".snapshot-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	err = readSnapshot(func(name string, content []byte) error {
+		if name == ".commit" {
+			return os.WriteFile(filepath.Join(tmpDir, snapshotMarker), content, 0644)
+		}
+
+		// アーカイブ外へのパスは拒否する
+		clean := path.Clean(name)
+		if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+			return fmt.Errorf("invalid path in snapshot: %s", name)
+		}
+		target := filepath.Join(tmpDir, filepath.FromSlash(clean))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(target, content, 0644)
+	})
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpDir, dir)
+}
+
+// isSnapshot reports whether dir was populated from the embedded snapshot
+func isSnapshot(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, snapshotMarker))
+	return err == nil
+}
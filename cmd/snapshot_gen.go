@@ -0,0 +1,274 @@
+//go:build ignore
+
+// snapshot_gen.go builds snapshot.tar.gz from a local clone of github/gitignore,
+// or from the Go module proxy's archive of a github/gitignore commit.
+//
+//	go run snapshot_gen.go -src ~/.cache/mushi/github-gitignore -out snapshot.tar.gz
+//	go run snapshot_gen.go -proxy -ref main -out snapshot.tar.gz
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// modulePath は github/gitignore をモジュールプロキシから取得するときのパスです
+const modulePath = "github.com/github/gitignore"
+
+func main() {
+	src := flag.String("src", defaultSrc(), "Path to a clone of github/gitignore")
+	proxy := flag.Bool("proxy", false, "Download github/gitignore from the Go module proxy instead of reading -src")
+	ref := flag.String("ref", "main", "Branch, tag or commit to download with -proxy")
+	out := flag.String("out", "snapshot.tar.gz", "Path to the output archive")
+	flag.Parse()
+
+	var (
+		commit string
+		files  map[string][]byte
+		err    error
+		from   = *src
+	)
+	if *proxy {
+		from = proxyURL() + "/" + modulePath
+		commit, files, err = readProxy(*ref)
+	} else {
+		commit, files, err = readClone(*src)
+	}
+	if err == nil {
+		err = writeArchive(*out, commit, files)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error building snapshot: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote %d templates from %s (%s) to %s\n", len(files), from, commit, *out)
+}
+
+// defaultSrc returns mushi's cache directory for github/gitignore
+func defaultSrc() string {
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		cacheHome = filepath.Join(os.Getenv("HOME"), ".cache")
+	}
+	return filepath.Join(cacheHome, "mushi", "github-gitignore")
+}
+
+// isCommit reports whether s is a full lowercase commit SHA
+func isCommit(s string) bool {
+	return len(s) == 40 && strings.Trim(s, "0123456789abcdef") == ""
+}
+
+// sourceCommit returns the commit src is checked out at.
+// スナップショットから作ったキャッシュや親ディレクトリのリポジトリを誤って使わないよう、
+// src 自体が git のクローンで、HEAD が完全なコミットハッシュであることを確認します
+func sourceCommit(src string) (string, error) {
+	output, err := exec.Command("git", "-C", src, "rev-parse", "--show-toplevel", "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("%s is not a clone of github/gitignore: %w", src, err)
+	}
+	lines := strings.Fields(string(output))
+	if len(lines) != 2 {
+		return "", fmt.Errorf("unexpected output from git rev-parse: %q", output)
+	}
+
+	top, err := filepath.EvalSymlinks(lines[0])
+	if err != nil {
+		return "", err
+	}
+	dir, err := filepath.Abs(src)
+	if err != nil {
+		return "", err
+	}
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		return "", err
+	}
+	if top != dir {
+		return "", fmt.Errorf("%s is not the top of a git clone (found %s)", src, top)
+	}
+
+	if !isCommit(lines[1]) {
+		return "", fmt.Errorf("unexpected commit %q in %s", lines[1], src)
+	}
+	return lines[1], nil
+}
+
+// readClone reads every .gitignore file in the clone at src
+func readClone(src string) (string, map[string][]byte, error) {
+	commit, err := sourceCommit(src)
+	if err != nil {
+		return "", nil, err
+	}
+
+	files := make(map[string][]byte)
+	err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".gitignore") {
+			return nil
+		}
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(relPath)] = content
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return commit, files, nil
+}
+
+// proxyURL returns the first HTTP(S) entry of GOPROXY, or proxy.golang.org
+func proxyURL() string {
+	for _, entry := range strings.FieldsFunc(os.Getenv("GOPROXY"), func(r rune) bool { return r == ',' || r == '|' }) {
+		if strings.HasPrefix(entry, "https://") || strings.HasPrefix(entry, "http://") {
+			return strings.TrimSuffix(entry, "/")
+		}
+	}
+	return "https://proxy.golang.org"
+}
+
+// fetch downloads url and returns its body
+func fetch(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s: %s", url, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// readProxy downloads the module archive of github/gitignore at ref from the module proxy.
+// プロキシが返すコミット（Origin.Hash）をスナップショットのコミットとして記録します
+func readProxy(ref string) (string, map[string][]byte, error) {
+	base := proxyURL() + "/" + modulePath + "/@v/"
+	body, err := fetch(base + ref + ".info")
+	if err != nil {
+		return "", nil, err
+	}
+	var info struct {
+		Version string
+		Origin  struct {
+			URL  string
+			Hash string
+		}
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return "", nil, fmt.Errorf("failed to parse module info: %w", err)
+	}
+	if !isCommit(info.Origin.Hash) {
+		return "", nil, fmt.Errorf("module proxy did not report the commit of %s@%s", modulePath, ref)
+	}
+
+	archive, err := fetch(base + info.Version + ".zip")
+	if err != nil {
+		return "", nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return "", nil, err
+	}
+
+	// モジュールのアーカイブは "<module>@<version>/" の下にファイルを置く
+	prefix := modulePath + "@" + info.Version + "/"
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		name, ok := strings.CutPrefix(f.Name, prefix)
+		if !ok || f.FileInfo().IsDir() || !strings.HasSuffix(name, ".gitignore") {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return "", nil, err
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return "", nil, err
+		}
+		files[name] = content
+	}
+	if len(files) == 0 {
+		return "", nil, fmt.Errorf("no templates in %s@%s", modulePath, info.Version)
+	}
+	return info.Origin.Hash, files, nil
+}
+
+// writeArchive writes files into a reproducible tar.gz at out.
+// 先頭の ".commit" エントリにはスナップショット元のコミットを記録します
+func writeArchive(out, commit string, files map[string][]byte) error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	// 再現可能なアーカイブにするため時刻は固定する
+	write := func(name string, content []byte) error {
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(content)),
+			ModTime: time.Unix(0, 0),
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := tw.Write(content)
+		return err
+	}
+
+	if err := write(".commit", []byte(commit+"\n")); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := write(name, files[name]); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtractSnapshot(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "github-gitignore")
+
+	if err := extractSnapshot(dir); err != nil {
+		t.Fatalf("extractSnapshot failed: %v", err)
+	}
+
+	if !isSnapshot(dir) {
+		t.Error("extracted cache should be marked as a snapshot")
+	}
+
+	// common.gitignore のデフォルトがインポートするテンプレートは含まれているべき
+	for _, name := range []string{"Global/macOS", "Global/Windows", "Global/Linux", "community/OpenSSL", "Go"} {
+		if _, err := os.Stat(filepath.Join(dir, name+".gitignore")); err != nil {
+			t.Errorf("expected template %s in snapshot: %v", name, err)
+		}
+	}
+
+	expected, err := snapshotCommit()
+	if err != nil {
+		t.Fatalf("snapshotCommit failed: %v", err)
+	}
+	// スナップショットは実際のクローンから作り、完全なコミットハッシュを持つべき
+	if !isFullCommit(expected) {
+		t.Errorf("expected snapshot commit to be a 40-character hex SHA, got %q", expected)
+	}
+	commit, err := cacheCommit(dir)
+	if err != nil {
+		t.Fatalf("cacheCommit failed: %v", err)
+	}
+	if commit != expected {
+		t.Errorf("expected commit %s, got %s", expected, commit)
+	}
+}
+
+func TestEnsureCacheFallsBackToSnapshot(t *testing.T) {
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+
+	// git が使えない環境を再現する
+	t.Setenv("PATH", "")
+
+	if err := EnsureCache(cacheDir, false); err != nil {
+		t.Fatalf("EnsureCache failed: %v", err)
+	}
+	if !isSnapshot(cacheDir) {
+		t.Fatal("cache should be populated from the snapshot")
+	}
+
+	// 更新に失敗してもスナップショットは残る
+	if err := EnsureCache(cacheDir, false); err != nil {
+		t.Fatalf("EnsureCache failed: %v", err)
+	}
+	if !isSnapshot(cacheDir) {
+		t.Error("snapshot cache should be kept when the update fails")
+	}
+}
@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// defaultSourceName は github/gitignore を指す組み込みソースの名前です
+	defaultSourceName = "github"
+	// defaultSourceURL は組み込みソースのリポジトリ URL です
+	defaultSourceURL = "https://github.com/github/gitignore"
+	// defaultSourceDir は組み込みソースのキャッシュディレクトリ名です
+	defaultSourceDir = "github-gitignore"
+	// cloneDirSuffix はクローンし直す間の一時ディレクトリに付ける接尾辞です
+	cloneDirSuffix = ".clone"
+)
+
+// SourceConfig は config.toml の [[sources]] の1項目です。
+// URL は git リポジトリ、Path は git を使わずにそのまま読むディレクトリを指します
+type SourceConfig struct {
+	Name     string `mapstructure:"name"`
+	URL      string `mapstructure:"url"`
+	Path     string `mapstructure:"path"`
+	Ref      string `mapstructure:"ref"`
+	Priority int    `mapstructure:"priority"`
+}
+
+// Source はテンプレートの取得元と、そのキャッシュの場所です。
+// Local が true の場合、Dir はユーザーのディレクトリそのものでキャッシュではありません。
+// Ref が空でない場合、キャッシュはそのコミット・タグ・ブランチに固定されます
+type Source struct {
+	Name     string
+	URL      string
+	Ref      string
+	Priority int
+	Dir      string
+	Local    bool
+}
+
+// Sources は優先度の高い順に並んだテンプレートの取得元です
+type Sources []Source
+
+// newSources builds the source list from the built-in github source and configs.
+// 組み込みソースは cacheRoot/github-gitignore に、それ以外は cacheRoot/<name> にキャッシュします
+func newSources(cacheRoot string, configs []SourceConfig) (Sources, error) {
+	sources := Sources{{
+		Name: defaultSourceName,
+		URL:  defaultSourceURL,
+		Dir:  filepath.Join(cacheRoot, defaultSourceDir),
+	}}
+
+	for _, c := range configs {
+		// 名前はキャッシュのディレクトリ名になるため、cacheRoot の外や他のソースの場所を指せないようにする
+		if c.Name == "" || c.Name == "." || c.Name == ".." || strings.ContainsAny(c.Name, `:/\`) {
+			return nil, fmt.Errorf("invalid source name %q", c.Name)
+		}
+		if c.Name == defaultSourceDir || strings.HasSuffix(c.Name, cloneDirSuffix) {
+			return nil, fmt.Errorf("source name %q conflicts with a cache directory", c.Name)
+		}
+		// "file:" はファイルの取り込みに使うため予約されている
+		if c.Name+":" == fileImportPrefix {
+			return nil, fmt.Errorf("source name %q is reserved", c.Name)
+		}
+		if (c.URL == "") == (c.Path == "") {
+			return nil, fmt.Errorf("source %s must have exactly one of url or path", c.Name)
+		}
+		if _, ok := sources.find(c.Name); ok && c.Name != defaultSourceName {
+			return nil, fmt.Errorf("duplicate source %s", c.Name)
+		}
+
+		// ローカルディレクトリはキャッシュせずにそのまま読む
+		if c.Path != "" {
+			dir, err := filepath.Abs(expandHome(c.Path))
+			if err != nil {
+				return nil, fmt.Errorf("source %s: %w", c.Name, err)
+			}
+			src := Source{Name: c.Name, Priority: c.Priority, Dir: dir, Local: true}
+			if c.Name == defaultSourceName {
+				sources[0] = src
+			} else {
+				sources = append(sources, src)
+			}
+			continue
+		}
+
+		url := expandHome(c.URL)
+
+		// 組み込みソースと同名の場合は設定で上書きする
+		if c.Name == defaultSourceName {
+			sources[0].URL = url
+			sources[0].Ref = c.Ref
+			sources[0].Priority = c.Priority
+			continue
+		}
+		sources = append(sources, Source{
+			Name:     c.Name,
+			URL:      url,
+			Ref:      c.Ref,
+			Priority: c.Priority,
+			Dir:      filepath.Join(cacheRoot, c.Name),
+		})
+	}
+
+	// 優先度の高い順に並べる（同じ優先度は設定順）
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].Priority > sources[j].Priority
+	})
+	return sources, nil
+}
+
+// insideDir reports whether path is strictly inside the directory root
+func insideDir(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// singleSource returns a source list consisting only of the given cache directory
+func singleSource(cacheDir string) Sources {
+	return Sources{{Name: defaultSourceName, URL: defaultSourceURL, Dir: cacheDir}}
+}
+
+// getSources returns the configured template sources
+func getSources() (Sources, error) {
+	cacheDir, err := getCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	sources, err := newSources(filepath.Dir(cacheDir), config.Sources)
+	if err != nil {
+		return nil, err
+	}
+
+	// --ref と config.toml の ref は github/gitignore のキャッシュに適用する
+	// 優先順位は --ref、[[sources]] の ref、トップレベルの ref の順
+	for i := range sources {
+		if sources[i].Name != defaultSourceName || sources[i].Local {
+			continue
+		}
+		if pinRef != "" {
+			sources[i].Ref = pinRef
+		} else if sources[i].Ref == "" {
+			sources[i].Ref = config.Ref
+		}
+	}
+	return sources, nil
+}
+
+// Ensure makes sure every source is cached, updating them unless skipUpdate is set
+func (s Sources) Ensure(skipUpdate bool) error {
+	for _, src := range s {
+		if src.Local {
+			if err := ensureLocalDir(src); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := ensureRepository(src, skipUpdate); err != nil {
+			return fmt.Errorf("source %s: %w", src.Name, err)
+		}
+	}
+	return nil
+}
+
+// EnsureCloned clones every source that is not cached yet, without updating the others
+func (s Sources) EnsureCloned() error {
+	for _, src := range s {
+		if src.Local {
+			if err := ensureLocalDir(src); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := os.Stat(src.Dir); os.IsNotExist(err) {
+			if err := cloneOrExtract(src.URL, src.Dir, src.snapshotFallback()); err != nil {
+				return fmt.Errorf("source %s: %w", src.Name, err)
+			}
+			if src.Ref != "" {
+				if err := checkoutRef(src.Dir, src.Ref, false); err != nil {
+					return fmt.Errorf("source %s: %w", src.Name, err)
+				}
+			}
+		} else if src.Ref != "" && isSnapshot(src.Dir) {
+			// 固定されたソースがスナップショットのままなら、クローンし直して ref をチェックアウト
+			if err := replacePinnedSnapshot(src); err != nil {
+				return fmt.Errorf("source %s: %w", src.Name, err)
+			}
+			if err := checkoutRef(src.Dir, src.Ref, false); err != nil {
+				return fmt.Errorf("source %s: %w", src.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// snapshotFallback reports whether the embedded snapshot can stand in for the source.
+// ref で固定されている場合はスナップショットでは再現性が保てないため使いません
+func (src Source) snapshotFallback() bool {
+	return !src.Local && src.URL == defaultSourceURL && src.Ref == ""
+}
+
+// ensureLocalDir checks that a local source directory exists
+func ensureLocalDir(src Source) error {
+	info, err := os.Stat(src.Dir)
+	if err != nil {
+		return fmt.Errorf("source %s: %w", src.Name, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("source %s: %s is not a directory", src.Name, src.Dir)
+	}
+	return nil
+}
+
+// find returns the source with the given name
+func (s Sources) find(name string) (Source, bool) {
+	for _, src := range s {
+		if src.Name == name {
+			return src, true
+		}
+	}
+	return Source{}, false
+}
+
+// Path resolves a template name to its file
+func (s Sources) Path(name string) (string, error) {
+	_, path, err := s.Lookup(name)
+	return path, err
+}
+
+// Lookup resolves a template name to the source that provides it and its file.
+// "source:Name" はそのソースのみを、修飾されていない名前は優先度順にすべてのソースを探します
+func (s Sources) Lookup(name string) (Source, string, error) {
+	if sourceName, templateName, ok := strings.Cut(name, ":"); ok {
+		src, found := s.find(sourceName)
+		if !found {
+			return Source{}, "", fmt.Errorf("unknown source %s", sourceName)
+		}
+		path := filepath.Join(src.Dir, templateName+".gitignore")
+		if _, err := os.Stat(path); err != nil {
+			return Source{}, "", fmt.Errorf("template %s not found in source %s", templateName, sourceName)
+		}
+		return src, path, nil
+	}
+
+	for _, src := range s {
+		path := filepath.Join(src.Dir, name+".gitignore")
+		if _, err := os.Stat(path); err == nil {
+			return src, path, nil
+		}
+	}
+	return Source{}, "", fmt.Errorf("template %s not found", name)
+}
+
+// Templates returns every template name available from the sources.
+// 複数のソースがある場合は "source:Name" の形式で返します
+func (s Sources) Templates() ([]string, error) {
+	var templates []string
+	for _, src := range s {
+		names, err := findTemplates(src.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("source %s: %w", src.Name, err)
+		}
+		for _, name := range names {
+			if len(s) > 1 {
+				name = src.Name + ":" + name
+			}
+			templates = append(templates, name)
+		}
+	}
+	return templates, nil
+}
+
+// expandHome replaces a leading "~/" with the user's home directory
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home := os.Getenv("HOME")
+	if home == "" {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
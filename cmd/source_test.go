@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestNewSources(t *testing.T) {
+	cacheRoot := t.TempDir()
+
+	t.Run("orders sources by priority", func(t *testing.T) {
+		sources, err := newSources(cacheRoot, []SourceConfig{
+			{Name: "low", URL: "https://example.com/low.git", Priority: -1},
+			{Name: "company", URL: "https://example.com/company.git", Priority: 10},
+		})
+		if err != nil {
+			t.Fatalf("newSources failed: %v", err)
+		}
+
+		var names []string
+		for _, src := range sources {
+			names = append(names, src.Name)
+		}
+		expected := []string{"company", defaultSourceName, "low"}
+		if !slices.Equal(names, expected) {
+			t.Errorf("expected order %v, got %v", expected, names)
+		}
+		if sources[0].Dir != filepath.Join(cacheRoot, "company") {
+			t.Errorf("unexpected cache dir %s", sources[0].Dir)
+		}
+		if sources[1].Dir != filepath.Join(cacheRoot, "github-gitignore") {
+			t.Errorf("unexpected cache dir %s", sources[1].Dir)
+		}
+	})
+
+	t.Run("expands home in local paths", func(t *testing.T) {
+		t.Setenv("HOME", "/home/user")
+		sources, err := newSources(cacheRoot, []SourceConfig{{Name: "dotfiles", URL: "~/repos/ignores"}})
+		if err != nil {
+			t.Fatalf("newSources failed: %v", err)
+		}
+		src, _ := sources.find("dotfiles")
+		if src.URL != "/home/user/repos/ignores" {
+			t.Errorf("expected expanded path, got %s", src.URL)
+		}
+	})
+
+	errorCases := []struct {
+		name    string
+		configs []SourceConfig
+	}{
+		{name: "empty name", configs: []SourceConfig{{URL: "x"}}},
+		{name: "name with colon", configs: []SourceConfig{{Name: "a:b", URL: "x"}}},
+		{name: "dot", configs: []SourceConfig{{Name: ".", URL: "x"}}},
+		{name: "parent directory", configs: []SourceConfig{{Name: "..", URL: "x"}}},
+		{name: "built-in cache directory", configs: []SourceConfig{{Name: "github-gitignore", URL: "x"}}},
+		{name: "temporary clone directory", configs: []SourceConfig{{Name: "a.clone", URL: "x"}}},
+		{name: "missing url", configs: []SourceConfig{{Name: "a"}}},
+		{name: "duplicate", configs: []SourceConfig{{Name: "a", URL: "x"}, {Name: "a", URL: "y"}}},
+	}
+	for _, tt := range errorCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newSources(cacheRoot, tt.configs); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestSourcesPath(t *testing.T) {
+	tmpDir := t.TempDir()
+	sources := Sources{
+		{Name: "company", Dir: filepath.Join(tmpDir, "company"), Priority: 10},
+		{Name: defaultSourceName, Dir: filepath.Join(tmpDir, "github")},
+	}
+	files := map[string]string{
+		"company/Go.gitignore":       "company go",
+		"github/Go.gitignore":        "github go",
+		"github/Python.gitignore":    "python",
+		"company/Internal.gitignore": "internal",
+	}
+	for file, content := range files {
+		path := filepath.Join(tmpDir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+		wantErr  bool
+	}{
+		{name: "unqualified uses priority", template: "Go", expected: "company/Go.gitignore"},
+		{name: "unqualified falls back", template: "Python", expected: "github/Python.gitignore"},
+		{name: "qualified", template: "github:Go", expected: "github/Go.gitignore"},
+		{name: "qualified missing", template: "github:Internal", wantErr: true},
+		{name: "unknown source", template: "other:Go", wantErr: true},
+		{name: "missing", template: "Rust", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sources.Path(tt.template)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Path failed: %v", err)
+			}
+			if got != filepath.Join(tmpDir, tt.expected) {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+
+	t.Run("templates are qualified with several sources", func(t *testing.T) {
+		templates, err := sources.Templates()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"company:Go", "company:Internal", "github:Go", "github:Python"} {
+			if !slices.Contains(templates, name) {
+				t.Errorf("expected %s in %v", name, templates)
+			}
+		}
+	})
+}
+
+func TestLocalSource(t *testing.T) {
+	tmpDir := t.TempDir()
+	templatesDir := filepath.Join(tmpDir, "ignore-templates")
+	if err := os.MkdirAll(filepath.Join(templatesDir, "lang"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(templatesDir, "lang", "Zig.gitignore"), []byte("zig-out/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// git が無くても動作することを確認する
+	t.Setenv("PATH", "")
+
+	sources, err := newSources(filepath.Join(tmpDir, "cache"), []SourceConfig{
+		{Name: "monorepo", Path: templatesDir, Priority: 1},
+	})
+	if err != nil {
+		t.Fatalf("newSources failed: %v", err)
+	}
+	local := sources[0]
+	if local.Name != "monorepo" || !local.Local || local.Dir != templatesDir {
+		t.Fatalf("unexpected local source: %+v", local)
+	}
+
+	t.Run("ensure does not need git", func(t *testing.T) {
+		if err := (Sources{local}).Ensure(false); err != nil {
+			t.Errorf("Ensure failed: %v", err)
+		}
+		if err := (Sources{local}).EnsureCloned(); err != nil {
+			t.Errorf("EnsureCloned failed: %v", err)
+		}
+	})
+
+	t.Run("templates resolve from the directory", func(t *testing.T) {
+		path, err := sources.Path("monorepo:lang/Zig")
+		if err != nil {
+			t.Fatalf("Path failed: %v", err)
+		}
+		if path != filepath.Join(templatesDir, "lang", "Zig.gitignore") {
+			t.Errorf("unexpected path %s", path)
+		}
+
+		templates, err := (Sources{local}).Templates()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(templates, []string{"lang/Zig"}) {
+			t.Errorf("unexpected templates %v", templates)
+		}
+	})
+
+	t.Run("missing directory is an error", func(t *testing.T) {
+		missing := Sources{{Name: "gone", Dir: filepath.Join(tmpDir, "missing"), Local: true}}
+		if err := missing.Ensure(true); err == nil {
+			t.Error("expected error for missing directory")
+		}
+	})
+
+	t.Run("url and path are exclusive", func(t *testing.T) {
+		if _, err := newSources(tmpDir, []SourceConfig{{Name: "both", URL: "x", Path: "y"}}); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
+
+func TestNewSourcesReservedName(t *testing.T) {
+	if _, err := newSources(t.TempDir(), []SourceConfig{{Name: "file", Path: t.TempDir()}}); err == nil {
+		t.Errorf("newSources() accepted the reserved source name file")
+	}
+}
+
+func TestInsideDir(t *testing.T) {
+	root := filepath.Join("home", "user", ".cache", "mushi")
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{path: filepath.Join(root, "github-gitignore"), expected: true},
+		{path: filepath.Join(root, "company"), expected: true},
+		{path: root, expected: false},
+		{path: filepath.Dir(root), expected: false},
+		{path: filepath.Join(root, "..", "other"), expected: false},
+		{path: filepath.Join(root, "..mushi"), expected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := insideDir(root, tt.path); got != tt.expected {
+				t.Errorf("insideDir(%q, %q) = %v, expected %v", root, tt.path, got, tt.expected)
+			}
+		})
+	}
+}
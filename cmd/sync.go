@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// syncResult は1つの管理区画を再生成した結果です
+type syncResult struct {
+	Name    string
+	Added   int
+	Removed int
+	Err     error
+}
+
+// syncDocument re-renders every managed section of doc with render.
+// 再生成に失敗した区画は元の内容のまま残します
+func syncDocument(doc *Document, render func(name string) ([]byte, error), dedupe bool) []syncResult {
+	previous := doc.Sections()
+	results := make([]syncResult, len(previous))
+	for i, s := range previous {
+		results[i].Name = s.Name
+		content, err := render(s.Name)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		doc.Upsert(Section{Name: s.Name, Content: content})
+	}
+	// append と同じく、既にファイルにある共通パターンは共通区画から除く
+	doc.dedupeCommon()
+	if dedupe {
+		doc.Dedupe()
+	}
+
+	// 重複除去後の内容で追加・削除行数を数える
+	for i, s := range doc.Sections() {
+		if results[i].Err != nil {
+			continue
+		}
+		results[i].Added, results[i].Removed = countChanges(diffLines(splitLines(previous[i].Content), splitLines(s.Content)))
+	}
+	return results
+}
+
+// sectionRenderer returns a render function for syncDocument that reads each
+// section from common.gitignore or the sources.
+// 読み込んだテンプレート区画は mushi.lock の記録用に rendered へ追加します
+func sectionRenderer(sources Sources, configDir string, rendered *[]Section) func(name string) ([]byte, error) {
+	return func(name string) ([]byte, error) {
+		if name == commonSectionName {
+			return loadCommon(configDir, sources)
+		}
+		sections, err := loadTemplates(sources, []string{name})
+		if err != nil {
+			return nil, err
+		}
+		*rendered = append(*rendered, sections[0])
+		return sections[0].Content, nil
+	}
+}
+
+// printSyncSummary writes a per-section summary of the sync results
+func printSyncSummary(w io.Writer, results []syncResult) {
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			fmt.Fprintf(w, "  %s: skipped (%v)\n", r.Name, r.Err)
+		case r.Added == 0 && r.Removed == 0:
+			fmt.Fprintf(w, "  %s: unchanged\n", r.Name)
+		default:
+			fmt.Fprintf(w, "  %s: +%d -%d\n", r.Name, r.Added, r.Removed)
+		}
+	}
+}
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Refresh generated sections of .gitignore from the local cache",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// 既存の出力ファイルを読み込む
+		existingContent, err := os.ReadFile(outputPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", outputPath, err)
+			os.Exit(1)
+		}
+
+		doc, err := ParseDocument(existingContent)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", outputPath, err)
+			os.Exit(1)
+		}
+		if len(doc.Sections()) == 0 {
+			fmt.Printf("No mushi sections found in %s\n", outputPath)
+			return
+		}
+
+		// テンプレートの取得元を解決
+		sources, err := getSources()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving template sources: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 設定ディレクトリのパスを解決
+		configDir, err := getConfigDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting config directory: %v\n", err)
+			os.Exit(1)
+		}
+
+		// キャッシュの存在確認と更新
+		skipUpdate := noUpdate || config.NoUpdate
+		if err := sources.Ensure(skipUpdate); err != nil {
+			fmt.Fprintf(os.Stderr, "Error managing cache: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 各区画を現在のキャッシュから再生成
+		var rendered []Section
+		results := syncDocument(doc, sectionRenderer(sources, configDir, &rendered), !noDedupe)
+		finalContent := doc.Bytes()
+
+		// --print が指定されたら標準出力に表示し、概要は標準エラー出力へ
+		if print {
+			os.Stdout.Write(finalContent)
+			printSyncSummary(os.Stderr, results)
+			return
+		}
+
+		// --diff / --dry-run が指定されたら差分のみ表示
+		if showDiff || dryRun {
+			previewDiff(outputPath, finalContent, showDiff)
+			return
+		}
+
+		// 結果を出力ファイルに書き込み
+		if err := os.WriteFile(outputPath, finalContent, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing to %s: %v\n", outputPath, err)
+			os.Exit(1)
+		}
+
+		// mushi.lock に生成内容を記録
+		if !noLock {
+			if err := recordLock(outputPath, finalContent, doc, sources, configDir, rendered, !noDedupe); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to update %s: %v\n", lockFileName, err)
+			}
+		}
+
+		fmt.Printf("✨️ Successfully synced %s\n", outputPath)
+		printSyncSummary(os.Stdout, results)
+	},
+}
+
+func init() {
+	syncCmd.Flags().BoolVar(&noUpdate, "no-update", false, "Skip updating the local cache")
+	syncCmd.Flags().StringVar(&pinRef, "ref", "", "Pin the github/gitignore cache to a commit SHA, tag or branch")
+	syncCmd.Flags().BoolVar(&noDedupe, "no-dedupe", false, "Keep duplicate patterns across sections")
+	syncCmd.Flags().BoolVar(&print, "print", false, "Print the result to stdout instead of writing to .gitignore")
+	syncCmd.Flags().BoolVar(&showDiff, "diff", false, "Print a unified diff instead of writing, and exit with status 1 if the file would change")
+	syncCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print a unified diff instead of writing")
+	syncCmd.Flags().BoolVar(&noLock, "no-lock", false, "Do not record the result in mushi.lock")
+	syncCmd.Flags().StringVarP(&outputPath, "path", "p", ".gitignore", "Path to output file (default: .gitignore)")
+	RootCmd.AddCommand(syncCmd)
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSyncDocument(t *testing.T) {
+	input := "# mine\nlocal/\n\n# >>> mushi: Go\nbin/\n*.exe\n# <<< mushi: Go\n\n# >>> mushi: Gone\nold/\n# <<< mushi: Gone\n"
+	doc, err := ParseDocument([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := syncDocument(doc, func(name string) ([]byte, error) {
+		if name == "Go" {
+			return []byte("bin/\n*.test\nvendor/\n"), nil
+		}
+		return nil, errors.New("template not found")
+	}, true)
+
+	expected := "# mine\nlocal/\n\n# >>> mushi: Go\nbin/\n*.test\nvendor/\n# <<< mushi: Go\n\n# >>> mushi: Gone\nold/\n# <<< mushi: Gone\n"
+	if string(doc.Bytes()) != expected {
+		t.Errorf("expected:\n%q\ngot:\n%q", expected, doc.Bytes())
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if r := results[0]; r.Name != "Go" || r.Added != 2 || r.Removed != 1 || r.Err != nil {
+		t.Errorf("unexpected result for Go: %+v", r)
+	}
+	if r := results[1]; r.Name != "Gone" || r.Err == nil {
+		t.Errorf("expected error result for Gone: %+v", r)
+	}
+}
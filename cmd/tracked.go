@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// TrackedMatch is a file tracked by git that a new ignore file would ignore
+type TrackedMatch struct {
+	Path string
+	Rule *IgnoreRule
+}
+
+// trackedFiles lists the files git tracks below dir, relative to dir.
+// git のリポジトリでない場合は空の一覧を返します
+func trackedFiles(dir string) ([]string, error) {
+	if err := exec.Command("git", "-C", dir, "rev-parse", "--is-inside-work-tree").Run(); err != nil {
+		return nil, nil
+	}
+
+	out, err := exec.Command("git", "-C", dir, "ls-files", "-z").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-files failed: %w", err)
+	}
+
+	var files []string
+	for _, name := range bytes.Split(out, []byte{0}) {
+		if len(name) > 0 {
+			files = append(files, string(name))
+		}
+	}
+	return files, nil
+}
+
+// newlyIgnoredFiles returns the tracked files that content ignores but previous does not.
+// ls-files は .gitignore のあるディレクトリからの相対パスを返すため、そのまま照合できます
+func newlyIgnoredFiles(files []string, previous, content []byte) []TrackedMatch {
+	before := NewMatcher(previous)
+	after := NewMatcher(content)
+
+	var matches []TrackedMatch
+	for _, file := range files {
+		rule, ignored := after.Match(file, false)
+		if !ignored {
+			continue
+		}
+		if _, was := before.Match(file, false); was {
+			continue
+		}
+		matches = append(matches, TrackedMatch{Path: file, Rule: rule})
+	}
+	return matches
+}
+
+// checkTrackedFiles warns about tracked files that the new content for path
+// would start ignoring, and exits unless force is set
+func checkTrackedFiles(path string, content []byte, force bool) {
+	files, err := trackedFiles(filepath.Dir(path))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not list tracked files: %v\n", err)
+		return
+	}
+
+	// 既存の内容で既に無視されていたファイルは報告しない
+	previous, _ := os.ReadFile(path)
+	matches := newlyIgnoredFiles(files, previous, content)
+	if len(matches) == 0 {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Warning: the new %s would ignore %d tracked file(s):\n", path, len(matches))
+	for _, m := range matches {
+		fmt.Fprintf(os.Stderr, "  %s (%s, line %d)\n", m.Path, strings.TrimSpace(m.Rule.Pattern), m.Rule.Line)
+	}
+	if !force {
+		fmt.Fprintf(os.Stderr, "Error: refusing to write %s. Use -f or --force to write anyway.\n", path)
+		os.Exit(1)
+	}
+}
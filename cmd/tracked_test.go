@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTrackedFiles(t *testing.T) {
+	repo := newTestRepo(t)
+	for _, file := range []string{"main.go", "bin/tool", "sub/Cargo.lock", "untracked.log"} {
+		repo.write(file, file)
+	}
+	repo.git("add", "main.go", "bin/tool", "sub/Cargo.lock")
+
+	files, err := trackedFiles(repo.dir)
+	if err != nil {
+		t.Fatalf("trackedFiles failed: %v", err)
+	}
+	expected := []string{"bin/tool", "main.go", "sub/Cargo.lock"}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("trackedFiles() = %v, expected %v", files, expected)
+	}
+
+	// サブディレクトリからは相対パスで返る
+	files, err = trackedFiles(filepath.Join(repo.dir, "sub"))
+	if err != nil {
+		t.Fatalf("trackedFiles failed: %v", err)
+	}
+	if !reflect.DeepEqual(files, []string{"Cargo.lock"}) {
+		t.Errorf("trackedFiles(sub) = %v, expected [Cargo.lock]", files)
+	}
+
+	// git のリポジトリでなければ空
+	files, err = trackedFiles(t.TempDir())
+	if err != nil || files != nil {
+		t.Errorf("trackedFiles outside a repository = %v, %v, expected nil", files, err)
+	}
+}
+
+func TestNewlyIgnoredFiles(t *testing.T) {
+	files := []string{"main.go", "bin/tool", "sub/Cargo.lock", "vendor/lib.go", "keep.lock"}
+	previous := []byte("vendor/\n")
+	content := []byte("vendor/\nbin/\n*.lock\n!keep.lock\n")
+
+	matches := newlyIgnoredFiles(files, previous, content)
+
+	var paths []string
+	var lines []int
+	for _, m := range matches {
+		paths = append(paths, m.Path)
+		lines = append(lines, m.Rule.Line)
+	}
+	if !reflect.DeepEqual(paths, []string{"bin/tool", "sub/Cargo.lock"}) {
+		t.Errorf("newlyIgnoredFiles() paths = %v", paths)
+	}
+	if !reflect.DeepEqual(lines, []int{2, 3}) {
+		t.Errorf("newlyIgnoredFiles() lines = %v", lines)
+	}
+}
@@ -13,13 +13,20 @@ var (
 	noUpdate    bool
 	print       bool
 	outputPath  string
+	noDedupe    bool
+	showDiff    bool
+	dryRun      bool
+	pinRef      string
+	noLock      bool
+	force       bool
+	category    string
 )
 
 // getCacheDir returns the path to the cache directory
 func getCacheDir() (string, error) {
 	cacheHome := os.Getenv("XDG_CACHE_HOME")
 	if cacheHome != "" {
-		return filepath.Join(cacheHome, "mushi", "github-gitignore"), nil
+		return filepath.Join(cacheHome, "mushi", defaultSourceDir), nil
 	}
 
 	home := os.Getenv("HOME")
@@ -27,30 +34,159 @@ func getCacheDir() (string, error) {
 		return "", fmt.Errorf("HOME environment variable is not set")
 	}
 
-	return filepath.Join(home, ".cache", "mushi", "github-gitignore"), nil
+	return filepath.Join(home, ".cache", "mushi", defaultSourceDir), nil
 }
 
 // ResolveImports は、content 内の "#Import:template" 行を展開して、
 // 対応するテンプレートの内容に置き換えます。
 func ResolveImports(content []byte, cacheDir string) ([]byte, error) {
+	return resolveImports(content, singleSource(cacheDir))
+}
+
+// resolveImports expands "#Import:template" lines using templates from sources
+func resolveImports(content []byte, sources Sources) ([]byte, error) {
+	return expandImports(content, sources, false, importFrame{})
+}
+
+// maxImportDepth は #Import: を入れ子で展開できる深さの上限です
+const maxImportDepth = 8
+
+// importFrame は展開中のファイルです。path が同じファイルは同一とみなします。
+// allowFiles はそのファイルの中でローカルファイルの取り込みを許すかどうかで、
+// 利用者自身が書いた common.gitignore、取り込んだファイル、ローカルのソースに限ります
+type importFrame struct {
+	name       string
+	path       string
+	allowFiles bool
+}
+
+// importChain formats frames as "A → B → C" for error messages
+func importChain(frames []importFrame) string {
+	names := make([]string, len(frames))
+	for i, f := range frames {
+		names[i] = f.name
+	}
+	return strings.Join(names, " → ")
+}
+
+// fileImportPrefix は "#Import:" でテンプレートの代わりにファイルを指定する接頭辞です
+const fileImportPrefix = "file:"
+
+// parseImportDirective parses an "#Import:" or "#Include:" line.
+// "#Include: path" と "#Import: file:path" はファイルを、それ以外の "#Import:" はテンプレートを指します
+func parseImportDirective(line string) (target string, isFile bool, ok bool) {
+	switch {
+	case strings.HasPrefix(line, "#Include:"):
+		target = strings.TrimSpace(strings.TrimPrefix(line, "#Include:"))
+		return strings.TrimPrefix(target, fileImportPrefix), true, true
+	case strings.HasPrefix(line, "#Import:"):
+		target = strings.TrimSpace(strings.TrimPrefix(line, "#Import:"))
+		if rest, found := strings.CutPrefix(target, fileImportPrefix); found {
+			return rest, true, true
+		}
+		return target, false, true
+	}
+	return "", false, false
+}
+
+// includePath resolves a file import relative to the directory of the including file.
+// 取り込み元のパスが分からない場合は作業ディレクトリを基準にします
+func includePath(target, including string) (string, error) {
+	path := expandHome(target)
+	if !filepath.IsAbs(path) && including != "" {
+		path = filepath.Join(filepath.Dir(including), path)
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("file %s not found", path)
+	}
+	return path, nil
+}
+
+// expandImports expands "#Import:template" lines using templates from sources,
+// and "#Include:path" or "#Import:file:path" lines using local files,
+// following imports inside imported files up to maxImportDepth.
+// root は content を読み込んだファイルで、循環の検出とエラーメッセージに使います。
+// annotate が true の場合、展開した内容の前後に取り込み元を示すコメントを付けます
+func expandImports(content []byte, sources Sources, annotate bool, root importFrame) ([]byte, error) {
+	return expandImportChain(content, sources, annotate, []importFrame{root})
+}
+
+// expandImportChain expands the imports of the last file in chain
+func expandImportChain(content []byte, sources Sources, annotate bool, chain []importFrame) ([]byte, error) {
 	var result []byte
 	lines := strings.Split(string(content), "\n")
 
 	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "#Import:") {
-			templateName := strings.TrimSpace(trimmed[8:])
+		templateName, isFile, ok := parseImportDirective(strings.TrimSpace(line))
+		if ok {
 			if templateName == "" {
 				continue
 			}
 
-			templatePath := filepath.Join(cacheDir, templateName+".gitignore")
+			// git から取得したテンプレートが手元の任意のファイルを読み込まないようにする
+			including := chain[len(chain)-1]
+			if isFile && !including.allowFiles {
+				fmt.Fprintf(os.Stderr, "Warning: skipping file include %s in %s: only common.gitignore, included files and local sources may include files\n", templateName, including.name)
+				continue
+			}
+
+			// ファイルの取り込みは取り込み元のファイルからの相対パスで解決する
+			var origin, templatePath string
+			var err error
+			allowFiles := isFile
+			if isFile {
+				origin = "file"
+				templatePath, err = includePath(templateName, including.path)
+			} else {
+				var src Source
+				src, templatePath, err = sources.Lookup(templateName)
+				origin = src.Name
+				allowFiles = src.Local
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to import %s: %v\n", templateName, err)
+				continue
+			}
+
+			// 展開中のファイルを再び取り込む場合は循環としてエラー
+			frame := importFrame{name: templateName, path: templatePath, allowFiles: allowFiles}
+			for _, f := range chain {
+				if f.path != "" && f.path == templatePath {
+					return nil, fmt.Errorf("import cycle: %s", importChain(append(chain, frame)))
+				}
+			}
+			if len(chain) > maxImportDepth {
+				return nil, fmt.Errorf("imports nested deeper than %d: %s", maxImportDepth, importChain(append(chain, frame)))
+			}
+
 			imported, err := os.ReadFile(templatePath)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: failed to import %s: %v\n", templateName, err)
 				continue
 			}
 
+			// 取り込んだテンプレートの中の #Import: も展開する。
+			// 展開で末尾に付く改行を取り除き、元のファイルと同じ形に戻す
+			expanded, err := expandImportChain(imported, sources, annotate, append(chain[:len(chain):len(chain)], frame))
+			if err != nil {
+				return nil, err
+			}
+			imported = expanded[:len(expanded)-1]
+
+			if annotate {
+				result = append(result, fmt.Sprintf("# Imported from %s (%s: %s)\n", templateName, origin, templatePath)...)
+				result = append(result, imported...)
+				if len(imported) > 0 && imported[len(imported)-1] != '\n' {
+					result = append(result, '\n')
+				}
+				result = append(result, fmt.Sprintf("# End of %s\n", templateName)...)
+				continue
+			}
+
 			result = append(result, imported...)
 			result = append(result, '\n')
 		} else {
@@ -76,3 +212,22 @@ func getConfigDir() (string, error) {
 
 	return filepath.Join(home, ".config", "mushi"), nil
 }
+
+// previewDiff prints a unified diff between the file at path and content without writing it.
+// failOnDiff が true で差分がある場合は終了コード 1 で終了します
+func previewDiff(path string, content []byte, failOnDiff bool) {
+	fromName := path
+	current, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		fromName = "/dev/null"
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", path, err)
+		os.Exit(1)
+	}
+
+	diff := unifiedDiff(current, content, fromName, path)
+	os.Stdout.Write(diff)
+	if len(diff) > 0 && failOnDiff {
+		os.Exit(1)
+	}
+}
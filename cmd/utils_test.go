@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -231,3 +233,241 @@ func TestResolveImports(t *testing.T) {
 		})
 	}
 }
+
+func TestExpandImportsAnnotate(t *testing.T) {
+	cacheDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(cacheDir, "Go.gitignore"), []byte("bin/\n*.exe"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := expandImports([]byte(".env\n#Import:Go\n*.log"), singleSource(cacheDir), true, importFrame{})
+	if err != nil {
+		t.Fatalf("expandImports failed: %v", err)
+	}
+
+	path := filepath.Join(cacheDir, "Go.gitignore")
+	expected := ".env\n# Imported from Go (github: " + path + ")\nbin/\n*.exe\n# End of Go\n*.log\n"
+	if string(got) != expected {
+		t.Errorf("expandImports() =\n%q\nexpected\n%q", got, expected)
+	}
+}
+
+func TestExpandImportsRecursive(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name+".gitignore"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sources := Sources{{Name: "local", Dir: dir, Local: true}}
+
+	t.Run("nested", func(t *testing.T) {
+		write("Base", "*.log\n")
+		write("Web", "#Import:Base\nnode_modules/\n")
+
+		got, err := expandImports([]byte("#Import:Web\n.env"), sources, false, importFrame{name: "common.gitignore"})
+		if err != nil {
+			t.Fatalf("expandImports failed: %v", err)
+		}
+		expected := "*.log\n\nnode_modules/\n\n.env\n"
+		if string(got) != expected {
+			t.Errorf("expandImports() = %q, expected %q", got, expected)
+		}
+	})
+
+	t.Run("same template twice is not a cycle", func(t *testing.T) {
+		write("Base", "*.log\n")
+		write("Both", "#Import:Base\n#Import:Base\n")
+		if _, err := expandImports([]byte("#Import:Both\n"), sources, false, importFrame{name: "common.gitignore"}); err != nil {
+			t.Errorf("expandImports failed: %v", err)
+		}
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		write("A", "a/\n#Import:B\n")
+		write("B", "b/\n#Import:A\n")
+
+		_, err := expandImports([]byte("#Import:A\n"), sources, false, importFrame{name: "common.gitignore"})
+		if err == nil || err.Error() != "import cycle: common.gitignore → A → B → A" {
+			t.Errorf("expandImports() error = %v", err)
+		}
+	})
+
+	t.Run("self import from root", func(t *testing.T) {
+		write("Self", "x/\n#Import:Self\n")
+		path := filepath.Join(dir, "Self.gitignore")
+
+		_, err := loadTemplates(sources, []string{"Self"})
+		if err == nil || err.Error() != "failed to resolve imports in template Self: import cycle: Self → Self" {
+			t.Errorf("loadTemplates() error = %v", err)
+		}
+		if _, err := expandImports([]byte("#Import:Self\n"), sources, false, importFrame{name: "Self", path: path}); err == nil {
+			t.Errorf("expandImports() did not detect the cycle")
+		}
+	})
+
+	t.Run("depth limit", func(t *testing.T) {
+		for i := 0; i <= maxImportDepth; i++ {
+			write(fmt.Sprintf("L%d", i), fmt.Sprintf("l%d/\n#Import:L%d\n", i, i+1))
+		}
+		write(fmt.Sprintf("L%d", maxImportDepth+1), "end/\n")
+
+		_, err := expandImports([]byte("#Import:L0\n"), sources, false, importFrame{name: "common.gitignore"})
+		if err == nil || !strings.HasPrefix(err.Error(), fmt.Sprintf("imports nested deeper than %d: common.gitignore → L0 → L1", maxImportDepth)) {
+			t.Errorf("expandImports() error = %v", err)
+		}
+	})
+
+	t.Run("templates resolve imports", func(t *testing.T) {
+		write("Base", "*.log\n")
+		write("Web", "#Import:Base\nnode_modules/\n")
+
+		sections, err := loadTemplates(sources, []string{"Web"})
+		if err != nil {
+			t.Fatalf("loadTemplates failed: %v", err)
+		}
+		if string(sections[0].Content) != "*.log\n\nnode_modules/\n" {
+			t.Errorf("loadTemplates() content = %q", sections[0].Content)
+		}
+	})
+}
+
+func TestExpandImportsFiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	configDir := filepath.Join(home, "config")
+	snippets := filepath.Join(configDir, "snippets")
+	dotfiles := filepath.Join(home, "work", "ignores")
+	for _, dir := range []string{snippets, dotfiles} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cacheDir := t.TempDir()
+	write(filepath.Join(cacheDir, "Go.gitignore"), "*.exe\n")
+	sources := singleSource(cacheDir)
+
+	// 相対パスは取り込み元のファイルのディレクトリから解決する
+	write(filepath.Join(snippets, "team.gitignore"), "team/\n#Include: ./nested/more.gitignore\n#Import:Go\n")
+	if err := os.MkdirAll(filepath.Join(snippets, "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	write(filepath.Join(snippets, "nested", "more.gitignore"), "more/\n")
+	write(filepath.Join(dotfiles, "secrets.gitignore"), "*.pem\n")
+
+	commonPath := filepath.Join(configDir, "common.gitignore")
+	common := ".env\n#Include: ./snippets/team.gitignore\n#Import: file:~/work/ignores/secrets.gitignore\n"
+
+	t.Run("resolve", func(t *testing.T) {
+		got, err := expandImports([]byte(common), sources, false, importFrame{name: "common.gitignore", path: commonPath, allowFiles: true})
+		if err != nil {
+			t.Fatalf("expandImports failed: %v", err)
+		}
+		expected := ".env\nteam/\nmore/\n\n*.exe\n\n\n*.pem\n\n\n"
+		if string(got) != expected {
+			t.Errorf("expandImports() = %q, expected %q", got, expected)
+		}
+	})
+
+	t.Run("annotate", func(t *testing.T) {
+		got, err := expandImports([]byte("#Import: file:~/work/ignores/secrets.gitignore\n"), sources, true, importFrame{name: "common.gitignore", path: commonPath, allowFiles: true})
+		if err != nil {
+			t.Fatalf("expandImports failed: %v", err)
+		}
+		expected := "# Imported from ~/work/ignores/secrets.gitignore (file: " + filepath.Join(dotfiles, "secrets.gitignore") + ")\n*.pem\n# End of ~/work/ignores/secrets.gitignore\n\n"
+		if string(got) != expected {
+			t.Errorf("expandImports() = %q, expected %q", got, expected)
+		}
+	})
+
+	t.Run("missing file is skipped", func(t *testing.T) {
+		got, err := expandImports([]byte("a/\n#Include: ./missing.gitignore\n"), sources, false, importFrame{name: "common.gitignore", path: commonPath, allowFiles: true})
+		if err != nil {
+			t.Fatalf("expandImports failed: %v", err)
+		}
+		if string(got) != "a/\n\n" {
+			t.Errorf("expandImports() = %q", got)
+		}
+	})
+
+	// git から取得したテンプレートは手元のファイルを取り込めない
+	t.Run("git template cannot include files", func(t *testing.T) {
+		secret := filepath.Join(home, "secret")
+		write(secret, "TOPSECRET\n")
+		write(filepath.Join(cacheDir, "Evil.gitignore"), "evil/\n#Include: "+secret+"\n#Import: file:"+secret+"\n")
+
+		got, err := expandImports([]byte("#Import:Evil\n"), sources, false, importFrame{name: "common.gitignore", path: commonPath, allowFiles: true})
+		if err != nil {
+			t.Fatalf("expandImports failed: %v", err)
+		}
+		if strings.Contains(string(got), "TOPSECRET") {
+			t.Errorf("expandImports() included a local file from a git template: %q", got)
+		}
+
+		got, err = expandImports([]byte("#Include: "+secret+"\n"), sources, false, templateFrame(sources, "Evil", filepath.Join(cacheDir, "Evil.gitignore")))
+		if err != nil {
+			t.Fatalf("expandImports failed: %v", err)
+		}
+		if strings.Contains(string(got), "TOPSECRET") {
+			t.Errorf("expandImports() included a local file into a git template: %q", got)
+		}
+	})
+
+	t.Run("local source can include files", func(t *testing.T) {
+		localDir := t.TempDir()
+		write(filepath.Join(localDir, "Team.gitignore"), "#Include: ./extra.gitignore\n")
+		write(filepath.Join(localDir, "extra.gitignore"), "extra/\n")
+		local := Sources{{Name: "team", Dir: localDir, Local: true}}
+
+		got, err := expandImports([]byte("#Import:Team\n"), local, false, importFrame{name: "common.gitignore", path: commonPath, allowFiles: true})
+		if err != nil {
+			t.Fatalf("expandImports failed: %v", err)
+		}
+		if string(got) != "extra/\n\n\n\n" {
+			t.Errorf("expandImports() = %q", got)
+		}
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		write(filepath.Join(snippets, "loop.gitignore"), "#Include: ../common.gitignore\n")
+		write(commonPath, "#Include: snippets/loop.gitignore\n")
+
+		_, err := expandImports([]byte("#Include: snippets/loop.gitignore\n"), sources, false, importFrame{name: "common.gitignore", path: commonPath, allowFiles: true})
+		if err == nil || err.Error() != "import cycle: common.gitignore → snippets/loop.gitignore → ../common.gitignore" {
+			t.Errorf("expandImports() error = %v", err)
+		}
+	})
+}
+
+func TestParseImportDirective(t *testing.T) {
+	tests := []struct {
+		line   string
+		target string
+		isFile bool
+		ok     bool
+	}{
+		{line: "#Import:Go", target: "Go", ok: true},
+		{line: "#Import: github:Global/macOS", target: "github:Global/macOS", ok: true},
+		{line: "#Import: file:~/secrets.gitignore", target: "~/secrets.gitignore", isFile: true, ok: true},
+		{line: "#Include: ./team.gitignore", target: "./team.gitignore", isFile: true, ok: true},
+		{line: "# Import: Go"},
+		{line: "*.log"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			target, isFile, ok := parseImportDirective(tt.line)
+			if target != tt.target || isFile != tt.isFile || ok != tt.ok {
+				t.Errorf("parseImportDirective(%q) = %q, %v, %v", tt.line, target, isFile, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check that .gitignore still matches mushi.lock",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// mushi.lock から対象ファイルの記録を読み込む
+		lock, err := readLockfile(lockPath(outputPath))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", lockFileName, err)
+			os.Exit(1)
+		}
+		entry, ok := lock.Files[filepath.Base(outputPath)]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: %s has no entry for %s\n", lockPath(outputPath), outputPath)
+			os.Exit(1)
+		}
+
+		content, err := os.ReadFile(outputPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", outputPath, err)
+			os.Exit(1)
+		}
+
+		// テンプレートの取得元を解決（再現性のためキャッシュは更新しない）
+		sources, err := getSources()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving template sources: %v\n", err)
+			os.Exit(1)
+		}
+		if err := sources.EnsureCloned(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error managing cache: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 設定ディレクトリのパスを解決
+		configDir, err := getConfigDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting config directory: %v\n", err)
+			os.Exit(1)
+		}
+
+		problems, regenerated, err := verifyFile(content, entry, sources, configDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error regenerating %s: %v\n", outputPath, err)
+			os.Exit(1)
+		}
+
+		if len(problems) == 0 {
+			fmt.Printf("✨️ %s matches %s\n", outputPath, lockFileName)
+			return
+		}
+
+		for _, problem := range problems {
+			fmt.Printf("✗ %s\n", problem)
+		}
+		os.Stdout.Write(unifiedDiff(content, regenerated, outputPath, outputPath+" (from "+lockFileName+")"))
+		os.Exit(1)
+	},
+}
+
+func init() {
+	verifyCmd.Flags().StringVarP(&outputPath, "path", "p", ".gitignore", "Path to output file (default: .gitignore)")
+	RootCmd.AddCommand(verifyCmd)
+}